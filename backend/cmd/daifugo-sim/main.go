@@ -0,0 +1,186 @@
+// daifugo-sim plays games between bots with the daifugo engine and reports the results,
+// so that the effects of the special rules can be compared.
+//
+//	go run ./cmd/daifugo-sim -games 10000 -players 4 -strategies weakest,random -rules Yagiri,KakumeiRule
+//
+// The output is a text summary by default. -format json writes the whole report,
+// and -format csv writes a row per game. -rules standard leaves out the special rules which
+// are not implemented yet, such as ShibariRule, and the text summary lists them as not simulated.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go-playground/daifugo/engine"
+)
+
+func main() {
+	numGames := flag.Int("games", 1000, "number of games")
+	numPlayers := flag.Int("players", 4, "number of players")
+	decks := flag.Int("decks", 0, "number of decks combined, 2 for up to 10 players (default enough for the players)")
+	strategies := flag.String("strategies", string(engine.RandomStrategy),
+		"comma separated strategies assigned to the seats in order: "+joinStrategies(engine.Strategies))
+	rules := flag.String("rules", "standard", `comma separated special rules, "standard" or "none"`)
+	seed := flag.Uint64("seed", 1, "random seed")
+	format := flag.String("format", "text", "output format: text, json or csv")
+	output := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+	if *decks == 0 {
+		*decks = engine.DecksFor(*numPlayers)
+	}
+	specialRules, leftOut := parseRules(*rules)
+	for _, rule := range leftOut {
+		log.Printf("%s is not implemented yet and is left out of the standard rules", rule)
+	}
+
+	options := engine.SimulationOptions{
+		NumGames: *numGames,
+		NumPlayers: *numPlayers,
+		Strategies: parseStrategies(*strategies),
+		SpecialRules: specialRules,
+		Decks: *decks,
+		Seed: *seed,
+	}
+	report, err := engine.Simulate(options)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var writer io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		writer = file
+	}
+	switch *format {
+	case "text":
+		err = writeText(writer, report, leftOut)
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	case "csv":
+		err = writeCSV(writer, report)
+	default:
+		err = fmt.Errorf("unknown format: %s", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func joinStrategies(strategies []engine.Strategy) string {
+	names := make([]string, len(strategies))
+	for i, strategy := range strategies {
+		names[i] = string(strategy)
+	}
+	return strings.Join(names, ", ")
+}
+
+func parseStrategies(value string) []engine.Strategy {
+	strategies := make([]engine.Strategy, 0)
+	for _, name := range strings.Split(value, ",") {
+		strategies = append(strategies, engine.Strategy(strings.TrimSpace(name)))
+	}
+	return strategies
+}
+
+// parseRules returns the special rules of value. The standard rules which have no effect yet
+// are rejected by the simulation, so they are left out and returned as leftOut.
+func parseRules(value string) (rules []engine.SpecialRule, leftOut []engine.SpecialRule) {
+	rules = make([]engine.SpecialRule, 0)
+	switch value {
+	case "none", "":
+		return rules, nil
+	case "standard":
+		for rule := range engine.StandardRule {
+			if slices.Contains(engine.UnimplementedRules, rule) {
+				leftOut = append(leftOut, rule)
+			} else {
+				rules = append(rules, rule)
+			}
+		}
+		slices.Sort(rules)
+		slices.Sort(leftOut)
+		return rules, leftOut
+	}
+	for _, name := range strings.Split(value, ",") {
+		rules = append(rules, engine.SpecialRule(strings.TrimSpace(name)))
+	}
+	return rules, nil
+}
+
+func writeText(writer io.Writer, report engine.SimulationReport, leftOut []engine.SpecialRule) error {
+	options := report.Options
+	lines := []string{
+		fmt.Sprintf("games: %d, players: %d, seed: %d", options.NumGames, options.NumPlayers, options.Seed),
+		fmt.Sprintf("strategies: %s", joinStrategies(options.Strategies)),
+		fmt.Sprintf("rules: %v", options.SpecialRules),
+	}
+	if len(leftOut) > 0 {
+		lines = append(lines, fmt.Sprintf("not simulated (not implemented yet): %v", leftOut))
+	}
+	lines = append(lines,
+		fmt.Sprintf("average actions per game: %.1f", report.AverageActions),
+		fmt.Sprintf("per game: Kakumei %.3f, Yagiri %.3f, Spade3 %.3f",
+			report.KakumeiPerGame, report.YagiriPerGame, report.Spade3PerGame),
+		"win rate by seat:",
+	)
+	for seat, rate := range report.WinRateBySeat {
+		lines = append(lines, fmt.Sprintf("  seat%d: %.3f", seat+1, rate))
+	}
+	lines = append(lines, "win rate by role:")
+	for _, role := range []engine.PlayerRole{engine.Daifugo, engine.Fugo, engine.Heimin, engine.Hinmin, engine.Daihinmin} {
+		if rate, ok := report.WinRateByRole[role]; ok {
+			lines = append(lines, fmt.Sprintf("  %s: %.3f", role, rate))
+		}
+	}
+	lines = append(lines, "win rate by strategy:")
+	for _, strategy := range engine.Strategies {
+		if rate, ok := report.WinRateByStrategy[strategy]; ok {
+			lines = append(lines, fmt.Sprintf("  %s: %.3f", strategy, rate))
+		}
+	}
+	_, err := fmt.Fprintln(writer, strings.Join(lines, "\n"))
+	return err
+}
+
+// writeCSV writes a row per game. Roles and ranks are separated by "|" in seat order.
+func writeCSV(writer io.Writer, report engine.SimulationReport) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write([]string{"game", "numActions", "winnerSeat", "roles", "ranks", "kakumei", "yagiri", "spade3"})
+	for _, record := range report.Records {
+		roles := make([]string, len(record.Roles))
+		for i, role := range record.Roles {
+			roles[i] = string(role)
+		}
+		ranks := make([]string, len(record.Ranks))
+		for i, rank := range record.Ranks {
+			ranks[i] = strconv.Itoa(rank)
+		}
+		csvWriter.Write([]string{
+			strconv.Itoa(record.Game),
+			strconv.Itoa(record.NumActions),
+			strconv.Itoa(record.WinnerSeat + 1),
+			strings.Join(roles, "|"),
+			strings.Join(ranks, "|"),
+			strconv.Itoa(record.Kakumei),
+			strconv.Itoa(record.Yagiri),
+			strconv.Itoa(record.Spade3),
+		})
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
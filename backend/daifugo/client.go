@@ -0,0 +1,74 @@
+package daifugo
+
+import (
+	"log"
+	"time"
+
+	"go-playground/daifugo/session"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time allowed to write a message to the client.
+	writeWait = 10 * time.Second
+	// Time allowed to read the next pong message from the client.
+	pongWait = 60 * time.Second
+	// Send pings to the client with this period. Must be less than pongWait.
+	pingPeriod = (pongWait * 9) / 10
+	// Maximum message size allowed from the client.
+	maxMessageSize = 8192
+)
+
+// writePump drains the outbound queue of client to conn and pings the client periodically.
+// Only writePump writes to conn, so gorilla's one-concurrent-writer rule is kept.
+// compact clients receive cards as CardStr. See CompactSubprotocol.
+func writePump(conn *websocket.Conn, client *session.Client, compact bool) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+	for {
+		select {
+		case message, ok := <-client.Messages():
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if compact {
+				message = compactMessage(message)
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				log.Printf("WebSocket write error: %v", err)
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("WebSocket ping error: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// readPump reads messages from conn and passes them to handleMessage
+// until the connection is closed or the client stops answering pings.
+func readPump(conn *websocket.Conn, handleMessage func(message []byte)) {
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			log.Printf("WebSocket read error: %v", err)
+			return
+		}
+		handleMessage(message)
+	}
+}
@@ -0,0 +1,72 @@
+package daifugo
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+
+	"go-playground/daifugo/engine"
+)
+
+// WebSocket subprotocols. A client chooses the encoding of the responses by
+// Sec-WebSocket-Protocol. Without it, the responses are encoded by JSONSubprotocol.
+const (
+	// JSONSubprotocol encodes a card as an object such as {"number":3,"value":3,"cardType":"Diamond"}.
+	JSONSubprotocol = "daifugo.v1.json"
+	// CompactSubprotocol encodes a card as a CardStr such as "3D", "13H" or "JOKER1".
+	// The message set is the same as JSONSubprotocol.
+	CompactSubprotocol = "daifugo.v1.compact"
+)
+
+// compactMessage replaces every card object in a JSON message with its CardStr.
+func compactMessage(message []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(message))
+	decoder.UseNumber()
+	var tree any
+	if err := decoder.Decode(&tree); err != nil {
+		log.Printf("cannot compact message: %v", err)
+		return message
+	}
+	compacted, err := json.Marshal(compactCards(tree))
+	if err != nil {
+		log.Printf("cannot compact message: %v", err)
+		return message
+	}
+	return compacted
+}
+
+func compactCards(tree any) any {
+	switch node := tree.(type) {
+	case map[string]any:
+		if card, ok := asCard(node); ok {
+			return engine.FormatCard(card)
+		}
+		for key, child := range node {
+			node[key] = compactCards(child)
+		}
+	case []any:
+		for i, child := range node {
+			node[i] = compactCards(child)
+		}
+	}
+	return tree
+}
+
+// asCard converts node to a Card if it has exactly the fields of Card.
+func asCard(node map[string]any) (engine.Card, bool) {
+	_, declared := node["asCardType"]
+	if len(node) != 3 && !(declared && len(node) == 5) {
+		return engine.Card{}, false
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		return engine.Card{}, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var card engine.Card
+	if err := decoder.Decode(&card); err != nil || card.CardType == "" {
+		return engine.Card{}, false
+	}
+	return card, true
+}
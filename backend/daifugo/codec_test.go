@@ -0,0 +1,64 @@
+package daifugo
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go-playground/daifugo/session"
+
+	"go-playground/daifugo/engine"
+
+	"github.com/gorilla/websocket"
+)
+
+func Test_compactMessage(t *testing.T) {
+	declaredJoker := engine.NewCard(-1, engine.Joker)
+	declaredJoker.AsNumber = 3
+	declaredJoker.AsCardType = engine.Heart
+	data, _ := json.Marshal(engine.PlayerView{
+		PlayerName: "p1",
+		HandCards: []engine.Card{engine.NewCard(3, engine.Diamond), engine.NewCard(-2, engine.Joker), declaredJoker},
+		LegalMoves: [][]engine.Card{{engine.NewCard(3, engine.Diamond)}},
+		IsMyTurn: true,
+	})
+	message, _ := json.Marshal(session.RawMessageResponse{Type: "PLAYER_STATE", Data: data})
+	want := `{"data":{"handCards":["3D","JOKER2","JOKER1:3H"],"isMyTurn":true,"legalMoves":[["3D"]],"playerName":"p1"},"type":"PLAYER_STATE"}`
+	if got := string(compactMessage(message)); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func Test_compactSubprotocol(t *testing.T) {
+	server := startTestServer(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/daifugo/ws/rooms/Test_compactSubprotocol/"
+	dialer := websocket.Dialer{Subprotocols: []string{CompactSubprotocol, JSONSubprotocol}}
+	p1, _, err := dialer.Dial(url+"p1", nil)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer p1.Close()
+	if p1.Subprotocol() != CompactSubprotocol {
+		t.Fatalf("unexpected subprotocol: %q", p1.Subprotocol())
+	}
+	readUntil(t, p1, "ADD_PLAYER")
+	p2 := dialPlayer(t, server, "Test_compactSubprotocol", "p2")
+	readUntil(t, p2, "ADD_PLAYER")
+
+	sendMessage(t, p1, `{"type": "GAME_START", "data": {"force": true}}`)
+	var compactState struct {
+		HandCards []engine.CardStr `json:"handCards"`
+	}
+	for len(compactState.HandCards) == 0 {
+		if err := json.Unmarshal(readUntil(t, p1, "PLAYER_STATE").Data, &compactState); err != nil {
+			t.Fatalf("hand cards are not compact: %v", err)
+		}
+	}
+	// clients without the subprotocol still get the card objects
+	var state engine.PlayerView
+	for len(state.HandCards) == 0 {
+		if err := json.Unmarshal(readUntil(t, p2, "PLAYER_STATE").Data, &state); err != nil {
+			t.Fatalf("hand cards are not objects: %v", err)
+		}
+	}
+}
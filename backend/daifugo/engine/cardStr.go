@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var cardStrPattern = regexp.MustCompile(`^(\d+)([SHDC])$`)
+
+var cardTypeLetters = map[CardType]string{
+	Spade: "S",
+	Club: "C",
+	Diamond: "D",
+	Heart: "H",
+}
+
+// FormatCard returns the CardStr of card. The Jokers are "JOKER1", "JOKER2" and so on,
+// followed by the substitute such as "JOKER1:3S" if declared.
+func FormatCard(card Card) CardStr {
+	if card.IsDeclared() {
+		return FormatCard(Card{Number: card.Number, CardType: Joker}) + ":" + FormatCard(NewCard(card.AsNumber, card.AsCardType))
+	}
+	if card.CardType == Joker {
+		return "JOKER" + strconv.Itoa(-card.Number)
+	}
+	return strconv.Itoa(card.Number) + cardTypeLetters[card.CardType]
+}
+
+// String returns the CardStr of card so that logs use the same notation as the clients.
+func (card Card) String() string {
+	return FormatCard(card)
+}
+
+// ParseCard parses a CardStr case-insensitively. "JOKER" is the same as "JOKER1".
+// A Joker can declare its substitute such as "JOKER2:3S".
+// Value is always derived by NewCard. The errors wrap ErrInvalidCard or ErrInvalidJoker.
+func ParseCard(cardStr CardStr) (Card, error) {
+	cardStr = strings.ToUpper(cardStr)
+	if jokerStr, substituteStr, ok := strings.Cut(cardStr, ":"); ok {
+		joker, err := ParseCard(jokerStr)
+		if err != nil || joker.CardType != Joker {
+			return Card{}, fmt.Errorf("%w: only jokers can substitute: %s", ErrInvalidJoker, cardStr)
+		}
+		substitute, err := ParseCard(substituteStr)
+		if err != nil || substitute.CardType == Joker {
+			return Card{}, fmt.Errorf("%w: %s", ErrInvalidJoker, cardStr)
+		}
+		joker.AsNumber = substitute.Number
+		joker.AsCardType = substitute.CardType
+		return joker, nil
+	}
+	if cardStr == "JOKER" {
+		return NewCard(-1, Joker), nil
+	}
+	if numberStr, ok := strings.CutPrefix(cardStr, "JOKER"); ok {
+		number, err := strconv.Atoi(numberStr)
+		if err != nil || number < 1 || number > 2*MaxDecks {
+			return Card{}, fmt.Errorf("%w: invalid joker: %s", ErrInvalidCard, cardStr)
+		}
+		return NewCard(-number, Joker), nil
+	}
+	matches := cardStrPattern.FindStringSubmatch(cardStr)
+	if len(matches) != 3 {
+		return Card{}, fmt.Errorf("%w: invalid format: %s", ErrInvalidCard, cardStr)
+	}
+	number, err := strconv.Atoi(matches[1])
+	if err != nil || number < 1 || number > 13 {
+		return Card{}, fmt.Errorf("%w: invalid number: %s", ErrInvalidCard, cardStr)
+	}
+	for cardType, letter := range cardTypeLetters {
+		if letter == matches[2] {
+			return NewCard(number, cardType), nil
+		}
+	}
+	return Card{}, fmt.Errorf("%w: invalid card type: %s", ErrInvalidCard, cardStr)
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"testing"
+)
+
+func Test_ParseCard(t *testing.T) {
+	tests := []struct {
+		cardStr CardStr
+		want Card
+		wantErr bool
+	}{
+		{"3S", NewCard(3, Spade), false},
+		{"10h", NewCard(10, Heart), false},
+		{"1D", NewCard(1, Diamond), false},
+		{"2C", NewCard(2, Club), false},
+		{"JOKER", NewCard(-1, Joker), false},
+		{"joker2", NewCard(-2, Joker), false},
+		{"JOKER2:3s", declareJoker(-2, 3, Spade), false},
+		{"3S:4S", Card{}, true},
+		{"JOKER1:JOKER2", Card{}, true},
+		{"14S", Card{}, true},
+		{"0H", Card{}, true},
+		{"3X", Card{}, true},
+		{"JOKER4", NewCard(-4, Joker), false},
+		{"JOKER5", Card{}, true},
+		{"JOKER0", Card{}, true},
+		{"", Card{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCard(tt.cardStr)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseCard(%q) = %+v, %v, want %+v", tt.cardStr, got, err, tt.want)
+		}
+	}
+	for _, card := range append(makeDeck(MaxDecks), declareJoker(-1, 13, Heart)) {
+		if got, err := ParseCard(FormatCard(card)); err != nil || got != card {
+			t.Errorf("round trip of %+v failed: %+v, %v", card, got, err)
+		}
+	}
+}
+
+func Test_FormatCard(t *testing.T) {
+	tests := []struct {
+		card Card
+		want CardStr
+	}{
+		{NewCard(3, Diamond), "3D"},
+		{NewCard(13, Heart), "13H"},
+		{NewCard(1, Spade), "1S"},
+		{NewCard(10, Club), "10C"},
+		{NewCard(-1, Joker), "JOKER1"},
+		{NewCard(-2, Joker), "JOKER2"},
+	}
+	for _, tt := range tests {
+		if got := FormatCard(tt.card); got != tt.want {
+			t.Errorf("FormatCard(%+v) = %s, want %s", tt.card, got, tt.want)
+		}
+	}
+}
+
+func FuzzParseCard(f *testing.F) {
+	for _, cardStr := range []CardStr{"3S", "10h", "JOKER", "JOKER2:13D", "0C", "99999999999999999999S", ":", "JOKER:"} {
+		f.Add(cardStr)
+	}
+	f.Fuzz(func(t *testing.T, cardStr string) {
+		card, err := ParseCard(cardStr)
+		if err != nil {
+			return
+		}
+		if err := validateDeclarations([]Card{card}, 1); err != nil {
+			t.Fatalf("%q is parsed to an invalid card %+v: %v", cardStr, card, err)
+		}
+		if card.Value != NewCard(card.Number, card.CardType).Value {
+			t.Fatalf("%q has a wrong value: %+v", cardStr, card)
+		}
+		if again, err := ParseCard(FormatCard(card)); err != nil || again != card {
+			t.Fatalf("%q is not formatted back: %+v, %+v, %v", cardStr, card, again, err)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package engine
+
+type CardType string
+const (
+	Club CardType = "Club"
+	Spade CardType = "Spade"
+	Heart CardType = "Heart"
+	Diamond CardType = "Diamond"
+	Joker CardType = "Joker"
+)
+
+// Card is a playing card. The Jokers have Number -1, -2 and so on to distinguish them.
+// With two decks the other cards have an identical duplicate.
+type Card struct {
+	Number int `json:"number"`
+	Value int `json:"value"`
+	CardType CardType `json:"cardType"`
+	// AsNumber and AsCardType are the card which a submitted Joker substitutes for.
+	// They are zero when the Joker is not declared, and it is a wildcard then.
+	AsNumber int `json:"asNumber,omitempty"`
+	AsCardType CardType `json:"asCardType,omitempty"`
+}
+const JokerValue = 99
+
+type CardStr = string // 3D, 13H, Joker etc...
+
+// NewCard returns the card of num and cardType with its Value.
+// 1 and 2 are stronger than 13, and Jokers are the strongest.
+func NewCard(num int, cardType CardType) Card {
+	v := num
+	if num <= 2 {
+		v += 13
+	}
+	if cardType == Joker {
+		v = JokerValue
+	}
+	return Card{Number: num, Value: v, CardType: cardType}
+}
+
+// SameCard reports whether a and b are the same physical card, ignoring the declaration of Jokers.
+func SameCard(a Card, b Card) bool {
+	return a.Number == b.Number && a.CardType == b.CardType
+}
+
+// IsDeclared reports whether card is a Joker which substitutes for another card.
+func (card Card) IsDeclared() bool {
+	return card.CardType == Joker && card.AsCardType != ""
+}
+
+// resolveJokers returns a copy of cards in which each declared Joker is replaced with
+// the card it substitutes for. Undeclared Jokers are kept as wildcards.
+func resolveJokers(cards []Card) []Card {
+	resolved := make([]Card, len(cards))
+	for i, card := range cards {
+		if card.IsDeclared() {
+			card = NewCard(card.AsNumber, card.AsCardType)
+		}
+		resolved[i] = card
+	}
+	return resolved
+}
+
+// makeDeck returns decks combined. Each deck has 4 * 13 cards and 2 Jokers.
+func makeDeck(decks int) []Card {
+	total := 54 * decks
+	ret := make([]Card, 0, total)
+	for deck := range decks {
+		// Jokers are distinguished by negative numbers, -1 and -2 in the first deck,
+		// -3 and -4 in the second, which are JOKER1 to JOKER4 in CardStr
+		ret = append(ret, NewCard(-2*deck-1, Joker))
+		ret = append(ret, NewCard(-2*deck-2, Joker))
+		for _, v := range []CardType{Club, Spade, Heart, Diamond} {
+			for i := 1; i <= 13; i++ {
+				ret = append(ret, NewCard(i, v))
+			}	
+		}
+	}
+	return ret
+}
+
+func countJokers(cards []Card) int {
+	count := 0
+	for _, card := range cards {
+		if card.CardType == Joker {
+			count++
+		}
+	}
+	return count
+}
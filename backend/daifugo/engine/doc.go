@@ -0,0 +1,9 @@
+// Package engine implements the rules of daifugo without any transport.
+//
+// A Game is created by NewGame and played by Start, Submit and Pass.
+// The rule violations are reported by the errors of this package, such as ErrNotYourTurn,
+// which can be tested with errors.Is. The state for a player and for the public is
+// returned by ViewFor and PublicState.
+//
+// A Game is not safe for concurrent use. The caller serializes the access to it.
+package engine
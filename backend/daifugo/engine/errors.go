@@ -0,0 +1,64 @@
+package engine
+
+// Language of the messages of RuleError.
+type Language string
+const (
+	Japanese Language = "ja"
+	English Language = "en"
+)
+
+// RuleError is an error reported by the engine when a request breaks the rules.
+// Code is a stable identifier which clients can use to translate the error,
+// and Messages has the text to show in each language.
+//
+// The errors below are the only values of RuleError. Some of them are wrapped with
+// the details, so use errors.Is to test them and errors.As to get the code.
+type RuleError struct {
+	Code string
+	Messages map[Language]string
+}
+
+func newRuleError(code string, en string, ja string) *RuleError {
+	return &RuleError{Code: code, Messages: map[Language]string{English: en, Japanese: ja}}
+}
+
+func (err *RuleError) Error() string {
+	return err.Messages[English]
+}
+
+// Message returns the message in language, or in English if it is not translated.
+func (err *RuleError) Message(language Language) string {
+	if message, ok := err.Messages[language]; ok {
+		return message
+	}
+	return err.Messages[English]
+}
+
+var (
+	ErrGameNotInProgress = newRuleError("GAME_NOT_IN_PROGRESS", "game is not in progress", "ゲームが始まっていません")
+	ErrNotYourTurn = newRuleError("NOT_YOUR_TURN", "not your turn", "あなたの番ではありません")
+	ErrPlayerNotFound = newRuleError("PLAYER_NOT_FOUND", "player not found", "プレイヤーが見つかりません")
+	ErrDuplicatedPlayer = newRuleError("DUPLICATED_PLAYER", "duplicated player name", "その名前は既に使われています")
+	ErrNotEnoughPlayers = newRuleError("NOT_ENOUGH_PLAYERS", "num of players is not enough", "プレイヤーが足りません")
+	ErrTooManyPlayers = newRuleError("TOO_MANY_PLAYERS", "num of players is too many", "プレイヤーが多すぎます")
+	ErrNoCards = newRuleError("NO_CARDS", "no cards selected", "カードが選ばれていません")
+	ErrCardNotInHand = newRuleError("CARD_NOT_IN_HAND", "cards not in hand", "手札にないカードです")
+	ErrInvalidJoker = newRuleError("INVALID_JOKER", "invalid joker substitute", "ジョーカーの代わりのカードが正しくありません")
+	// ErrInvalidCard is returned when a card cannot be parsed. See ParseCard.
+	ErrInvalidCard = newRuleError("INVALID_CARD", "invalid card", "カードの指定が正しくありません")
+	// ErrMixedRanks is returned when the cards are not of the same value.
+	ErrMixedRanks = newRuleError("MIXED_RANKS", "cards are not the same rank", "同じ数字のカードしか出せません")
+	// ErrCountMismatch is returned when the number of the cards differs from the field.
+	ErrCountMismatch = newRuleError("COUNT_MISMATCH", "num of cards differs from the field", "場と同じ枚数のカードを出してください")
+	ErrTooWeak = newRuleError("TOO_WEAK", "cards are not stronger than the field", "場のカードより強いカードを出してください")
+	ErrInvalidDecks = newRuleError("INVALID_DECKS", "invalid num of decks", "デッキ数が正しくありません")
+	ErrInvalidTeams = newRuleError("INVALID_TEAMS", "teams need an even num of players, 4 or more", "チーム戦には4人以上の偶数のプレイヤーが必要です")
+	ErrNotPassingToPartner = newRuleError("NOT_PASSING_TO_PARTNER", "cards cannot be passed to the partner now", "今はパートナーにカードを渡せません")
+	ErrAlreadyPassedToPartner = newRuleError("ALREADY_PASSED_TO_PARTNER", "cards are already passed to the partner", "既にパートナーにカードを渡しています")
+	ErrTooManyCards = newRuleError("TOO_MANY_CARDS", "too many cards", "カードが多すぎます")
+	ErrGamePaused = newRuleError("GAME_PAUSED", "game is paused", "ゲームは一時停止中です")
+	ErrNotPaused = newRuleError("NOT_PAUSED", "game is not paused", "ゲームは一時停止していません")
+	// ErrSuitLocked is returned when the suits are locked by Shibari. It is not returned yet,
+	// since Shibari is not implemented.
+	ErrSuitLocked = newRuleError("SUIT_LOCKED", "suits are locked", "縛りのため同じマークのカードしか出せません")
+)
@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func Test_RuleError(t *testing.T) {
+	for _, ruleError := range []*RuleError{
+		ErrGameNotInProgress, ErrNotYourTurn, ErrPlayerNotFound, ErrDuplicatedPlayer,
+		ErrNotEnoughPlayers, ErrTooManyPlayers, ErrNoCards, ErrCardNotInHand,
+		ErrInvalidJoker, ErrInvalidCard, ErrInvalidDecks, ErrMixedRanks, ErrCountMismatch, ErrTooWeak, ErrSuitLocked,
+		ErrInvalidTeams, ErrNotPassingToPartner, ErrAlreadyPassedToPartner, ErrTooManyCards,
+	} {
+		if ruleError.Code == "" || ruleError.Message(Japanese) == "" || ruleError.Message(English) == "" {
+			t.Errorf("%v should have a code and messages: %+v", ruleError, ruleError)
+		}
+		if ruleError.Message("fr") != ruleError.Message(English) {
+			t.Errorf("%v should fall back to English", ruleError)
+		}
+	}
+
+	err := fmt.Errorf("%w: p1", ErrPlayerNotFound)
+	var ruleError *RuleError
+	if !errors.As(err, &ruleError) || ruleError.Code != "PLAYER_NOT_FOUND" {
+		t.Errorf("wrapped error should keep the code: %v", err)
+	}
+}
@@ -0,0 +1,751 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+)
+
+type GameState string
+const (
+	WaitingForPlayers GameState = "WaitingForPlayers"
+	// PassingToPartner is the start of a team game, in which the players pass cards to their partners.
+	PassingToPartner GameState = "PassingToPartner"
+	PlayingCards GameState = "PlayingCards"
+	// Paused freezes a game in PassingToPartner or PlayingCards until Resume.
+	Paused GameState = "Paused"
+	GameEnded GameState = "GameEnded"
+)
+
+type PlayerRole string
+const (
+	Daifugo PlayerRole = "Daifugo"
+	Fugo PlayerRole = "Fugo"
+	Heimin PlayerRole = "Heimin"
+	Hinmin PlayerRole = "Hinmin"
+	Daihinmin PlayerRole = "Daihinmin"
+)
+
+const (
+	MinPlayers = 2
+	// MaxPlayers is the limit with a single deck.
+	MaxPlayers = 6
+	// MaxPlayersWithTwoDecks is the limit with two decks combined.
+	MaxPlayersWithTwoDecks = 10
+	MaxDecks = 2
+	// MaxPartnerPassCards is the limit of Options.PartnerPassCards.
+	MaxPartnerPassCards = 3
+)
+
+// DecksFor returns the num of decks needed for numPlayers.
+func DecksFor(numPlayers int) int {
+	if numPlayers > MaxPlayers {
+		return 2
+	}
+	return 1
+}
+
+// MaxPlayersFor returns the max num of players with decks.
+func MaxPlayersFor(decks int) int {
+	if decks >= 2 {
+		return MaxPlayersWithTwoDecks
+	}
+	return MaxPlayers
+}
+
+type Player struct {
+	Name string `json:"name"`
+	Role PlayerRole `json:"role"`
+	Cards []Card `json:"cards"`
+	// Team is the team of the player from 1 in a team game, otherwise 0.
+	Team int `json:"team"`
+}
+
+type SubmitMode string
+const (
+	Normal SubmitMode = "Normal"
+	ShibariMode SubmitMode = "ShibariMode"
+	KakumeiMode SubmitMode = "KakumeiMode"
+	KaidanMode SubmitMode = "KaidanMode"
+)
+
+type SpecialRule string
+const (
+Yagiri SpecialRule = "Yagiri"
+KakumeiRule SpecialRule = "KakumeiRule"
+ShibariRule SpecialRule = "ShibariRule"
+Spade3Rule SpecialRule = "Spade3Rule"
+//KaidanRule SpecialRule = "KaidanRule"
+)
+var StandardRule = map[SpecialRule]struct{}{
+	Yagiri: {},
+	KakumeiRule: {},
+	ShibariRule: {},
+	Spade3Rule: {},
+}
+
+// UnimplementedRules are the special rules which have no effect yet.
+var UnimplementedRules = []SpecialRule{ShibariRule}
+
+type Result struct {
+	GameNum int
+	PlayersByRank []string
+	// TeamsByRank are the teams in order of the sum of the ranks of their members in a team game.
+	TeamsByRank []int
+}
+
+type Game struct {
+	Players []*Player 
+	GameState GameState 
+	Turn int
+	LastSubmittedTurn int
+	SubmitModes map[SubmitMode]struct{}
+	SpecialRules map[SpecialRule]struct{}
+	PlayingCards []Card
+	LastSubmittedNum int
+	Trush []Card
+	PlayersByRank []string
+	PassCount int
+	Results []Result
+	// FixedSeats keeps the order of Players on Start instead of shuffling it.
+	FixedSeats bool
+	// Decks is the num of decks combined, 1 or 2.
+	Decks int
+	// Teams pairs the players sitting opposite each other.
+	Teams bool
+	// PartnerPassCards is the max num of cards a player can pass to the partner in a team game.
+	PartnerPassCards int
+	// PartnerPasses are the cards passed to the partners while PassingToPartner.
+	// They are exchanged when every player has passed.
+	PartnerPasses map[string][]Card
+	// PausedState is the state to return to on Resume while Paused.
+	PausedState GameState
+	// LeftPlayers are the players removed in the middle of the game, in the order they left.
+	// Those who had not finished forfeit and are ranked last, the first one at the bottom.
+	LeftPlayers []*Player
+	// rng shuffles the seats and the deck. The global source is used if it is nil.
+	// A seeded one makes games reproducible.
+	rng *rand.Rand
+}
+
+// Options configures NewGame.
+type Options struct {
+	// SpecialRules are the rules in effect. StandardRule is used if it is nil.
+	SpecialRules map[SpecialRule]struct{}
+	FixedSeats bool
+	// Decks is the num of decks combined. 1 is used if it is zero,
+	// and 2 allows up to MaxPlayersWithTwoDecks players.
+	Decks int
+	// Teams pairs the players sitting opposite each other, so it needs an even num of players, 4 or more.
+	// The result of a team is decided by the ranks of both members.
+	Teams bool
+	// PartnerPassCards allows each player of a team game to pass up to that many cards
+	// to the partner before playing. 0 disables it.
+	PartnerPassCards int
+	// Rand shuffles the seats and the deck. The global source is used if it is nil.
+	Rand *rand.Rand
+}
+
+// NewGame returns a game waiting for players.
+func NewGame(options Options) *Game {
+	specialRules := options.SpecialRules
+	if specialRules == nil {
+		specialRules = StandardRule
+	}
+	decks := options.Decks
+	if decks == 0 {
+		decks = 1
+	}
+	return &Game{
+		Players: make([]*Player, 0),
+		GameState: WaitingForPlayers,
+		SubmitModes: make(map[SubmitMode]struct{}),
+		SpecialRules: maps.Clone(specialRules),
+		PlayingCards: make([]Card, 0),
+		Turn: 0,
+		LastSubmittedTurn: -1,
+		PlayersByRank: make([]string, 0),
+		Results: make([]Result, 0),
+		FixedSeats: options.FixedSeats,
+		Decks: decks,
+		Teams: options.Teams,
+		PartnerPassCards: options.PartnerPassCards,
+		rng: options.Rand,
+	}
+}
+
+// Clone returns a deep copy of game, which can restore the state later.
+// The copy shares the random source with game.
+func (game *Game) Clone() *Game {
+	clone := *game
+	clone.Players = make([]*Player, len(game.Players))
+	for i, player := range game.Players {
+		copied := *player
+		copied.Cards = slices.Clone(player.Cards)
+		clone.Players[i] = &copied
+	}
+	clone.SubmitModes = maps.Clone(game.SubmitModes)
+	clone.SpecialRules = maps.Clone(game.SpecialRules)
+	clone.PlayingCards = slices.Clone(game.PlayingCards)
+	clone.Trush = slices.Clone(game.Trush)
+	clone.PlayersByRank = slices.Clone(game.PlayersByRank)
+	clone.Results = make([]Result, len(game.Results))
+	for i, result := range game.Results {
+		result.PlayersByRank = slices.Clone(result.PlayersByRank)
+		result.TeamsByRank = slices.Clone(result.TeamsByRank)
+		clone.Results[i] = result
+	}
+	clone.LeftPlayers = make([]*Player, len(game.LeftPlayers))
+	for i, player := range game.LeftPlayers {
+		copied := *player
+		copied.Cards = slices.Clone(player.Cards)
+		clone.LeftPlayers[i] = &copied
+	}
+	if game.PartnerPasses != nil {
+		clone.PartnerPasses = make(map[string][]Card, len(game.PartnerPasses))
+		for playerName, cards := range game.PartnerPasses {
+			clone.PartnerPasses[playerName] = slices.Clone(cards)
+		}
+	}
+	return &clone
+}
+
+func (game *Game) shuffle(n int, swap func(i, j int)) {
+	if game.rng != nil {
+		game.rng.Shuffle(n, swap)
+	} else {
+		rand.Shuffle(n, swap)
+	}
+}
+
+// Start deals the cards and starts a new game.
+// The roles are decided by the result of the previous game.
+func (game *Game) Start() error {
+	if len(game.Players) < MinPlayers {
+		return ErrNotEnoughPlayers
+	}
+	if game.Decks < 1 || game.Decks > MaxDecks {
+		return fmt.Errorf("%w: %d", ErrInvalidDecks, game.Decks)
+	}
+	if len(game.Players) > MaxPlayersFor(game.Decks) {
+		return ErrTooManyPlayers
+	}
+	if game.Teams && (len(game.Players) < 4 || len(game.Players)%2 != 0) {
+		return ErrInvalidTeams
+	}
+	game.GameState = PlayingCards
+	// clear the previous game
+	game.Turn = 0
+	game.LastSubmittedTurn = -1
+	game.LastSubmittedNum = 0
+	game.PassCount = 0
+	game.SubmitModes = make(map[SubmitMode]struct{})
+	game.PlayingCards = make([]Card, 0)
+	game.Trush = make([]Card, 0)
+	game.PlayersByRank = make([]string, 0)
+	game.LeftPlayers = nil
+	if !game.FixedSeats {
+		game.shuffle(len(game.Players), func(i, j int) {game.Players[i], game.Players[j] = game.Players[j], game.Players[i]})
+	}
+	for i, player := range game.Players {
+		player.Cards = make([]Card, 0)
+		player.Team = 0
+		if game.Teams {
+			player.Team = i%(len(game.Players)/2) + 1
+		}
+	}
+	game.PartnerPasses = nil
+	if game.Teams && game.PartnerPassCards > 0 {
+		game.GameState = PassingToPartner
+		game.PartnerPasses = make(map[string][]Card)
+	}
+	deck := makeDeck(game.Decks)
+	game.shuffle(len(deck), func(i, j int) {deck[i], deck[j] = deck[j], deck[i]})
+	for i, card := range deck {
+		game.Players[i%len(game.Players)].Cards = append(game.Players[i%len(game.Players)].Cards, card)
+	}
+	if len(game.Results) >= 1 {
+		previousResult := game.Results[len(game.Results)-1]
+		// the ranks are counted among the players still seated, since some may have left
+		rank := 0
+		for _, prevPlayer := range previousResult.PlayersByRank {
+			for _, player := range game.Players {
+				if prevPlayer == player.Name {
+					rank++
+					player.Role = decideRole(rank, len(game.Players))
+					break
+				}	
+			}
+		}
+	} else {
+		for _, player := range game.Players {
+			player.Role = Heimin
+		}
+	}
+	return nil
+}
+
+// decideRole returns the role of rank. With 4 or more players, the top two are
+// Daifugo and Fugo, the bottom two are Hinmin and Daihinmin, and the others are Heimin.
+func decideRole(rank, totalPlayers int) PlayerRole {
+	switch {
+		case rank < 1 || rank > totalPlayers:
+		return Heimin
+		case totalPlayers == 2: 
+		return []PlayerRole{Daifugo, Daihinmin}[rank-1]
+		case totalPlayers == 3: 
+		return []PlayerRole{Daifugo, Heimin, Daihinmin}[rank-1]
+		case rank == 1:
+		return Daifugo
+		case rank == 2:
+		return Fugo
+		case rank == totalPlayers:
+		return Daihinmin
+		case rank == totalPlayers-1:
+		return Hinmin
+		default: 
+		return Heimin
+	}
+}
+
+// AddPlayer seats a player at the end.
+func (game *Game) AddPlayer(playerName string) error {
+	if game.Player(playerName) != nil {
+		return ErrDuplicatedPlayer
+	}
+	game.Players = append(game.Players, &Player{Name: playerName})
+	return nil
+}
+
+// RemovePlayer removes a player from the seats.
+// A player who has not finished a game in progress forfeits: the hand goes to Trush,
+// and the player is ranked last. The game ends if only one player is left to play.
+func (game *Game) RemovePlayer(playerName string) error {
+	for i, player := range game.Players {
+		if player.Name == playerName {
+			if game.InProgress() {
+				game.leave(i)
+			} else {
+				game.Players = append(game.Players[:i], game.Players[i+1:]...)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrPlayerNotFound, playerName)
+}
+
+// leave removes the player at index i in the middle of the game,
+// keeping Turn and LastSubmittedTurn pointing at the same players.
+func (game *Game) leave(index int) {
+	player := game.Players[index]
+	// the passes count the remaining players only, which are compared with numActive below
+	if game.hasPassed(index) {
+		game.PassCount--
+	}
+	game.Trush = append(game.Trush, player.Cards...)
+	player.Cards = make([]Card, 0)
+	game.Players = append(game.Players[:index], game.Players[index+1:]...)
+	game.LeftPlayers = append(game.LeftPlayers, player)
+
+	if index < game.LastSubmittedTurn {
+		game.LastSubmittedTurn--
+	} else if index == game.LastSubmittedTurn {
+		// nobody owns the field. it is flushed when all the others pass
+		game.LastSubmittedTurn = -1
+	}
+	hadTurn := index == game.Turn
+	if index < game.Turn {
+		game.Turn--
+	}
+	if game.PartnerPasses != nil {
+		delete(game.PartnerPasses, player.Name)
+		if len(game.PartnerPasses) == len(game.Players) {
+			game.exchangePartnerPasses()
+		}
+	}
+
+	if game.numActive() <= 1 {
+		game.endGame()
+		return
+	}
+	if hadTurn {
+		// the next player, who is at index now, takes the turn
+		game.Turn = (index + len(game.Players) - 1) % len(game.Players)
+		game.advanceTurn()
+	}
+	if game.Turn == game.LastSubmittedTurn || game.PassCount >= game.numActive() {
+		game.discardPlayingCards()
+	}
+}
+
+// hasPassed reports whether the player at index has passed since the last submission.
+// The passes are made in turn by the players who have not finished just before the current one.
+func (game *Game) hasPassed(index int) bool {
+	seat := game.Turn
+	for passes := 0; passes < game.PassCount; {
+		seat = (seat + len(game.Players) - 1) % len(game.Players)
+		if seat == game.Turn {
+			return false
+		}
+		if slices.Contains(game.PlayersByRank, game.Players[seat].Name) {
+			continue
+		}
+		if seat == index {
+			return true
+		}
+		passes++
+	}
+	return false
+}
+
+// numActive returns the num of the seated players who have not finished.
+func (game *Game) numActive() int {
+	num := 0
+	for _, player := range game.Players {
+		if !slices.Contains(game.PlayersByRank, player.Name) {
+			num++
+		}
+	}
+	return num
+}
+
+// Abort ends the game in progress without recording its result.
+// The cards are collected and the players wait for the next game.
+func (game *Game) Abort() error {
+	if !game.InProgress() {
+		return ErrGameNotInProgress
+	}
+	game.GameState = WaitingForPlayers
+	game.PausedState = ""
+	for _, player := range game.Players {
+		player.Cards = make([]Card, 0)
+	}
+	game.PlayingCards = make([]Card, 0)
+	game.Trush = make([]Card, 0)
+	game.LastSubmittedNum = 0
+	game.PassCount = 0
+	game.PlayersByRank = make([]string, 0)
+	game.LeftPlayers = nil
+	game.PartnerPasses = nil
+	return nil
+}
+
+// Player returns the seated player of playerName, or nil.
+func (game *Game) Player(playerName string) *Player {
+	for _, player := range game.Players {
+		if player.Name == playerName {
+			return player
+		}
+	}
+	return nil
+}
+
+// InProgress reports whether a game is being played, including the passing to partners and a pause.
+func (game *Game) InProgress() bool {
+	return game.GameState == PassingToPartner || game.GameState == PlayingCards || game.GameState == Paused
+}
+
+// Pause freezes the game. Nothing can be played until Resume.
+func (game *Game) Pause() error {
+	if game.GameState == Paused {
+		return ErrGamePaused
+	}
+	if !game.InProgress() {
+		return ErrGameNotInProgress
+	}
+	game.PausedState = game.GameState
+	game.GameState = Paused
+	return nil
+}
+
+// Resume continues the paused game from where it was paused.
+func (game *Game) Resume() error {
+	if game.GameState != Paused {
+		return ErrNotPaused
+	}
+	game.GameState = game.PausedState
+	game.PausedState = ""
+	return nil
+}
+
+// Partner returns the player sitting opposite player in a team game, or nil.
+func (game *Game) Partner(player *Player) *Player {
+	if player.Team == 0 {
+		return nil
+	}
+	for _, other := range game.Players {
+		if other != player && other.Team == player.Team {
+			return other
+		}
+	}
+	return nil
+}
+
+// PassToPartner passes cards of playerName to the partner at the start of a team game.
+// Each player passes once, and no cards means passing nothing.
+// The cards are exchanged at once when every player has passed, so a card cannot be passed twice.
+func (game *Game) PassToPartner(playerName string, cards []Card) error {
+	if game.GameState == Paused {
+		return ErrGamePaused
+	}
+	if game.GameState != PassingToPartner {
+		return ErrNotPassingToPartner
+	}
+	player := game.Player(playerName)
+	if player == nil {
+		return fmt.Errorf("%w: %s", ErrPlayerNotFound, playerName)
+	}
+	if _, ok := game.PartnerPasses[playerName]; ok {
+		return ErrAlreadyPassedToPartner
+	}
+	if len(cards) > game.PartnerPassCards {
+		return ErrTooManyCards
+	}
+	if !player.hasCards(cards) {
+		return ErrCardNotInHand
+	}
+	passed := make([]Card, len(cards))
+	for i, card := range cards {
+		// a declaration of a Joker is meaningless in hand
+		passed[i] = NewCard(card.Number, card.CardType)
+	}
+	game.PartnerPasses[playerName] = passed
+	if len(game.PartnerPasses) == len(game.Players) {
+		game.exchangePartnerPasses()
+	}
+	return nil
+}
+
+// exchangePartnerPasses gives the passed cards to the partners and starts playing cards.
+// A player whose partner has left keeps the cards.
+func (game *Game) exchangePartnerPasses() {
+	passes := make(map[*Player][]Card)
+	for _, player := range game.Players {
+		if partner := game.Partner(player); partner != nil {
+			player.removeCards(game.PartnerPasses[player.Name])
+			passes[partner] = game.PartnerPasses[player.Name]
+		}
+	}
+	for partner, cards := range passes {
+		partner.Cards = append(partner.Cards, cards...)
+	}
+	game.PartnerPasses = nil
+	if game.GameState == Paused {
+		game.PausedState = PlayingCards
+	} else {
+		game.GameState = PlayingCards
+	}
+}
+
+// CurrentPlayer returns the player who has the turn.
+func (game *Game) CurrentPlayer() *Player {
+	if game.Turn < 0 || game.Turn >= len(game.Players) {
+		return nil
+	}
+	return game.Players[game.Turn]
+}
+
+// requireTurn checks that playerName can act now.
+func (game *Game) requireTurn(playerName string) (*Player, error) {
+	if game.GameState == Paused {
+		return nil, ErrGamePaused
+	}
+	if game.GameState != PlayingCards {
+		return nil, ErrGameNotInProgress
+	}
+	player := game.Player(playerName)
+	if player == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPlayerNotFound, playerName)
+	}
+	if player != game.CurrentPlayer() {
+		return nil, ErrNotYourTurn
+	}
+	return player, nil
+}
+
+// Pass passes the turn of playerName.
+// The field is cleared when everyone else has passed since the last submission.
+func (game *Game) Pass(playerName string) error {
+	if _, err := game.requireTurn(playerName); err != nil {
+		return err
+	}
+	game.pass()
+	return nil
+}
+
+func (game *Game) pass() {
+	game.advanceTurn()
+	game.PassCount++
+	if game.Turn == game.LastSubmittedTurn || game.PassCount >= game.numActive() {
+		game.discardPlayingCards()
+	}
+}
+
+// TopFieldCards returns the cards submitted last to the field.
+func (game *Game) TopFieldCards() []Card { 
+	return game.PlayingCards[len(game.PlayingCards)-game.LastSubmittedNum:]
+}
+
+// Submit submits cards from the hand of playerName to the field.
+// It returns the special rules triggered by them.
+func (game *Game) Submit(playerName string, submittingCards []Card) (SubmitEffects, error) {
+	player, err := game.requireTurn(playerName)
+	if err != nil {
+		return SubmitEffects{}, err
+	}
+	if !player.hasCards(submittingCards) {
+		return SubmitEffects{}, ErrCardNotInHand
+	}
+	if err := game.CanSubmit(submittingCards); err != nil {
+		return SubmitEffects{}, err
+	}
+	effects := game.EffectsOf(submittingCards)
+	game.LastSubmittedNum = len(submittingCards)
+	game.PlayingCards = append(game.PlayingCards, submittingCards...)
+	game.LastSubmittedTurn = game.Turn
+	game.PassCount = 0
+	game.advanceTurn()	
+
+	// Nagasu
+	if effects.Yagiri || effects.Spade3 {
+		game.discardPlayingCards()
+		game.Turn = game.LastSubmittedTurn
+		game.LastSubmittedNum = 0
+	}
+
+	if effects.Kakumei {
+		game.flipKakumei();
+	}
+
+	player.removeCards(submittingCards)
+	if len(player.Cards) == 0 {
+		game.PlayersByRank = append(game.PlayersByRank, player.Name)
+		if game.numActive() <= 1 {
+			game.endGame()
+		} else if game.CurrentPlayer() == player {
+			// the field was flushed by the last cards. the next player leads
+			game.advanceTurn()
+		}
+	}
+	
+	// TODO Shibari
+
+	return effects, nil
+}
+
+func (game *Game) endGame() {
+	game.GameState = GameEnded
+	game.PausedState = ""
+	game.PartnerPasses = nil
+	result := Result{}
+	result.GameNum = len(game.Results) + 1
+	result.PlayersByRank = slices.Clone(game.PlayersByRank)
+	for _, player := range game.Players {
+		found := true
+		for _, playerName := range result.PlayersByRank {
+			if player.Name == playerName {
+				found = false
+			}	
+		}
+		if found {
+			result.PlayersByRank = append(result.PlayersByRank, player.Name)
+		}
+	}
+	// the players who forfeited are below them, the first one at the bottom
+	for i := len(game.LeftPlayers) - 1; i >= 0; i-- {
+		if playerName := game.LeftPlayers[i].Name; !slices.Contains(game.PlayersByRank, playerName) {
+			result.PlayersByRank = append(result.PlayersByRank, playerName)
+		}
+	}
+	if game.Teams {
+		result.TeamsByRank = game.rankTeams(result.PlayersByRank)
+	}
+	game.Results = append(game.Results, result)
+}
+
+// rankTeams orders the teams by the sum of the ranks of their members.
+// A tie is broken by the best rank of the members.
+func (game *Game) rankTeams(playersByRank []string) []int {
+	sums := make(map[int]int)
+	bests := make(map[int]int)
+	teamOf := make(map[string]int)
+	for _, player := range slices.Concat(game.Players, game.LeftPlayers) {
+		teamOf[player.Name] = player.Team
+	}
+	for i, playerName := range playersByRank {
+		team := teamOf[playerName]
+		sums[team] += i + 1
+		if _, ok := bests[team]; !ok {
+			bests[team] = i + 1
+		}
+	}
+	teams := slices.Sorted(maps.Keys(sums))
+	slices.SortStableFunc(teams, func(a, b int) int {
+		if sums[a] != sums[b] {
+			return sums[a] - sums[b]
+		}
+		return bests[a] - bests[b]
+	})
+	return teams
+}
+
+// advanceTurn moves the turn to the next player who has not finished.
+// It gives up after a round, so it terminates even if everyone has finished.
+func (game *Game) advanceTurn() {
+	for range len(game.Players) {
+		game.Turn = (game.Turn + 1) % len(game.Players)	
+		currentPlayer := game.CurrentPlayer()
+		if !slices.Contains(game.PlayersByRank, currentPlayer.Name) {
+			return
+		}
+	}
+}
+
+// hasCards reports whether player has all of cards. Each card in hand can be used only once.
+func (player *Player) hasCards(cards []Card) bool {
+	used := make([]bool, len(player.Cards))
+	for _, card := range cards {
+		found := false
+		for i, playerCard := range player.Cards {
+			if !used[i] && SameCard(playerCard, card) {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// removeCards removes one card in hand for each of cards,
+// so only one of identical duplicates is removed for each.
+func (player *Player) removeCards(cards []Card) {
+	for _, card:= range cards {
+		i := slices.IndexFunc(player.Cards, func(playerCard Card) bool {
+			return SameCard(playerCard, card)
+		})
+		if i >= 0 {
+			player.Cards = slices.Delete(player.Cards, i, i+1)
+		}
+	}
+}
+
+func (game *Game) discardPlayingCards() {
+	game.Trush = append(game.Trush, game.PlayingCards...)
+	game.PlayingCards = make([]Card, 0)
+	game.LastSubmittedNum = 0
+	game.PassCount = 0
+	delete(game.SubmitModes, ShibariMode)
+}
+
+func (game *Game) flipKakumei() {
+	if _, ok := game.SubmitModes[KakumeiMode]; ok {
+		delete(game.SubmitModes, KakumeiMode)
+	} else {
+		game.SubmitModes[KakumeiMode] = struct{}{}
+	}
+}
@@ -0,0 +1,555 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+
+func Test_game(t *testing.T) {
+	game := NewGame(Options{})
+	err := game.Start()
+	if err == nil {
+		t.Errorf("should be error")
+	}
+	for _, playerName := range []string{"p1", "p2", "p3", "p4", "p5",} {
+		err = game.AddPlayer(playerName)
+		if err != nil {
+			t.Errorf("should not be error")
+		}
+	}
+	game.RemovePlayer("p5")
+	err = game.Start()
+	if err != nil {
+		t.Errorf("should not be error")
+	}
+	if len(game.Players) != 4 {
+		t.Errorf("num of players should be 4")
+	}
+	for _, player := range game.Players {
+		if player.Role != Heimin {
+			t.Errorf("all roles should be Heimin")
+		}
+		if !(13 <= len(player.Cards) && len(player.Cards) <= 14) {
+			t.Errorf("num of cards should be either 13 or 14")
+		}
+	}
+	if game.GameState != PlayingCards {
+		t.Errorf("GameState should be PlayingCards")
+	}
+	if game.Turn != 0 {
+		t.Errorf("turn should be 0")
+	}
+	
+	_, err = game.Submit(game.Players[1].Name, []Card{game.Players[1].Cards[0]})
+	if !errors.Is(err, ErrNotYourTurn) {
+		t.Errorf("err should be ErrNotYourTurn: %v", err)
+	}
+	// 8 flushes the field (Yagiri) and keeps the turn, so submit another card
+	firstCard := game.Players[0].Cards[0]
+	for _, card := range game.Players[0].Cards {
+		if card.Value != 8 {
+			firstCard = card
+			break
+		}
+	}
+	_, err = game.Submit(game.Players[0].Name, []Card{firstCard})
+	if err != nil {
+		t.Errorf("err should be nil: %v", err)
+	}
+	if len(game.PlayingCards) != 1 {
+		t.Errorf("len(game.PlayingCards) should be 1")
+	}
+	if game.Turn != 1 {
+		t.Errorf("Turn should be 1")
+	}
+	game.Pass(game.CurrentPlayer().Name)
+	if game.Turn != 2 {
+		t.Errorf("Turn should be 2")
+	}
+	game.Pass(game.CurrentPlayer().Name)
+	game.Pass(game.CurrentPlayer().Name)
+	if game.Turn != 0 {
+		t.Errorf("Turn should be 0")
+	}
+	if len(game.PlayingCards) != 0 {
+		t.Errorf("len(game.PlayingCards) should be 0")
+	}
+	// submitcards を、game.submitCards(player, cards)にするのか、player.submitCards(game, cards)にするのか？ん〜？
+	// 
+}
+
+func Test_CanSubmit(t *testing.T) {
+	type args struct {
+		topFieldCards, submittingCards []Card
+		submitModes map[SubmitMode]struct{}
+		specialRules map[SpecialRule]struct{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want error
+	}{
+		{"empty vs 4", args{[]Card{}, []Card{NewCard(4, Diamond)}, 
+			nil, maps.Clone(StandardRule)}, nil},
+		{"3 vs 4", args{[]Card{NewCard(3, Spade)}, []Card{NewCard(4, Diamond)}, 
+			nil, maps.Clone(StandardRule)}, nil},
+		{"4 vs 4", args{[]Card{NewCard(4, Spade)}, []Card{NewCard(4, Diamond)}, 
+			nil, maps.Clone(StandardRule)}, ErrTooWeak},
+		{"2 vs 3", args{[]Card{NewCard(2, Spade)}, []Card{NewCard(3, Diamond)}, 
+			nil, maps.Clone(StandardRule)}, ErrTooWeak},
+		{"3 vs 4 under kakumai", args{[]Card{NewCard(3, Spade)}, []Card{NewCard(4, Diamond)}, 
+			map[SubmitMode]struct{}{KakumeiMode: {}}, maps.Clone(StandardRule)}, ErrTooWeak},
+		{"Joker vs Spade 3", args{[]Card{NewCard(99, Joker)}, []Card{NewCard(3, Spade)}, 
+			nil, maps.Clone(StandardRule)}, nil},
+		{"3_3 vs 4_4", args{
+			[]Card{NewCard(3, Spade), NewCard(3, Diamond)}, 
+			[]Card{NewCard(4, Diamond), NewCard(4, Heart)}, 
+			nil, maps.Clone(StandardRule)}, nil},
+		{"3_3 vs 4_5", args{
+			[]Card{NewCard(3, Spade), NewCard(3, Diamond)}, 
+			[]Card{NewCard(4, Diamond), NewCard(5, Heart)}, 
+			nil, maps.Clone(StandardRule)}, ErrMixedRanks},
+		{"3_3 vs 4_5", args{
+			[]Card{NewCard(3, Spade), NewCard(3, Diamond)}, 
+			[]Card{NewCard(4, Diamond), NewCard(5, Heart)}, 
+			nil, maps.Clone(StandardRule)}, ErrMixedRanks},				
+		{"3_3 vs 4_Joker", args{
+			[]Card{NewCard(3, Spade), NewCard(3, Diamond)}, 
+			[]Card{NewCard(4, Diamond), NewCard(-1, Joker)}, 
+			nil, maps.Clone(StandardRule)}, nil},				
+		{"2 vs joker", args{[]Card{NewCard(2, Spade)}, []Card{NewCard(-1, Joker)}, 
+			nil, maps.Clone(StandardRule)}, nil},
+		{"2 vs joker under kakumai", args{[]Card{NewCard(2, Spade)}, []Card{NewCard(-1, Joker)}, 
+			map[SubmitMode]struct{}{KakumeiMode: {}}, maps.Clone(StandardRule)}, nil},
+		{"2_2 vs joker_joker", args{
+			[]Card{NewCard(2, Spade), NewCard(2, Diamond)},
+		  []Card{NewCard(-1, Joker), NewCard(-1, Joker)},
+			nil, maps.Clone(StandardRule)}, nil},
+		{"3_3 vs 4_Joker as 4", args{
+			[]Card{NewCard(3, Spade), NewCard(3, Diamond)}, 
+			[]Card{NewCard(4, Diamond), declareJoker(-1, 4, Heart)}, 
+			nil, maps.Clone(StandardRule)}, nil},
+		{"3_3 vs 4_Joker as 5", args{
+			[]Card{NewCard(3, Spade), NewCard(3, Diamond)}, 
+			[]Card{NewCard(4, Diamond), declareJoker(-1, 5, Heart)}, 
+			nil, maps.Clone(StandardRule)}, ErrMixedRanks},
+		{"Joker as a card in the same set", args{nil, 
+			[]Card{NewCard(4, Diamond), declareJoker(-2, 4, Diamond)}, 
+			nil, maps.Clone(StandardRule)}, ErrInvalidJoker},
+		{"5 vs Joker as 4", args{[]Card{NewCard(5, Spade)}, []Card{declareJoker(-2, 4, Diamond)}, 
+			nil, maps.Clone(StandardRule)}, ErrTooWeak},
+		{"Joker as 5 vs Joker as 6", args{[]Card{declareJoker(-1, 5, Spade)}, []Card{declareJoker(-2, 6, Diamond)}, 
+			nil, maps.Clone(StandardRule)}, nil},
+		{"Joker as 5 vs Spade 3", args{[]Card{declareJoker(-1, 5, Spade)}, []Card{NewCard(3, Spade)}, 
+			nil, maps.Clone(StandardRule)}, ErrTooWeak},
+		{"invalid substitute", args{nil, []Card{declareJoker(-1, 14, Spade)}, 
+			nil, maps.Clone(StandardRule)}, ErrInvalidJoker},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := NewGame(Options{})
+			game.PlayingCards = tt.args.topFieldCards
+			game.LastSubmittedNum = len(tt.args.topFieldCards)
+			game.SubmitModes = tt.args.submitModes
+			game.SpecialRules = tt.args.specialRules
+			if err := game.CanSubmit(tt.args.submittingCards); !errors.Is(err, tt.want) {
+				t.Errorf("%s failed with error:'%v'. want %v", tt.name, err, tt.want)
+			}
+		})
+	}
+}
+func declareJoker(number int, asNumber int, asCardType CardType) Card {
+	joker := NewCard(number, Joker)
+	joker.AsNumber = asNumber
+	joker.AsCardType = asCardType
+	return joker
+}
+
+func Test_removeJokers(t *testing.T) {
+	player := &Player{Name: "p1", Cards: []Card{NewCard(-1, Joker), NewCard(3, Spade), NewCard(-2, Joker)}}
+	if player.hasCards([]Card{NewCard(-1, Joker), NewCard(-1, Joker)}) {
+		t.Errorf("a card in hand must not be used twice")
+	}
+	if player.hasCards([]Card{NewCard(3, Heart)}) {
+		t.Errorf("a card not in hand must not be found")
+	}
+	submitted := []Card{NewCard(3, Spade), declareJoker(-2, 3, Heart)}
+	if !player.hasCards(submitted) {
+		t.Fatalf("declared joker must be found in hand")
+	}
+	player.removeCards(submitted)
+	if len(player.Cards) != 1 || player.Cards[0] != NewCard(-1, Joker) {
+		t.Errorf("unexpected hand after removal: %v", player.Cards)
+	}
+}
+
+func Test_effectsOf(t *testing.T) {
+	fourSixes := []Card{NewCard(6, Spade), NewCard(6, Heart), NewCard(6, Diamond), NewCard(6, Club)}
+	tests := []struct {
+		name string
+		topFieldCards []Card
+		submittingCards []Card
+		specialRules map[SpecialRule]struct{}
+		want SubmitEffects
+	}{
+		{"Kakumei", nil, fourSixes, maps.Clone(StandardRule), SubmitEffects{Kakumei: true}},
+		{"Kakumei disabled", nil, fourSixes, map[SpecialRule]struct{}{}, SubmitEffects{}},
+		{"Yagiri", nil, []Card{NewCard(8, Spade)}, maps.Clone(StandardRule), SubmitEffects{Yagiri: true}},
+		{"Joker as 8", nil, []Card{declareJoker(-1, 8, Spade)}, maps.Clone(StandardRule), SubmitEffects{Yagiri: true}},
+		{"Yagiri disabled", nil, []Card{NewCard(8, Spade)}, map[SpecialRule]struct{}{}, SubmitEffects{}},
+		{"Spade 3 on Joker", []Card{NewCard(-1, Joker)}, []Card{NewCard(3, Spade)},
+			maps.Clone(StandardRule), SubmitEffects{Spade3: true}},
+		{"Spade 3 on Joker disabled", []Card{NewCard(-1, Joker)}, []Card{NewCard(3, Spade)},
+			map[SpecialRule]struct{}{}, SubmitEffects{}},
+		{"Spade 3 on 4", []Card{NewCard(4, Heart)}, []Card{NewCard(3, Spade)}, maps.Clone(StandardRule), SubmitEffects{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := NewGame(Options{})
+			game.PlayingCards = tt.topFieldCards
+			game.LastSubmittedNum = len(tt.topFieldCards)
+			game.SpecialRules = tt.specialRules
+			if got := game.EffectsOf(tt.submittingCards); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_twoDecks(t *testing.T) {
+	game := NewGame(Options{})
+	for i := range MaxPlayers + 1 {
+		game.AddPlayer(fmt.Sprintf("p%d", i+1))
+	}
+	if err := game.Start(); !errors.Is(err, ErrTooManyPlayers) {
+		t.Errorf("%d players need two decks: %v", MaxPlayers+1, err)
+	}
+
+	game = NewGame(Options{Decks: 2})
+	for i := range MaxPlayersWithTwoDecks {
+		game.AddPlayer(fmt.Sprintf("p%d", i+1))
+	}
+	if err := game.Start(); err != nil {
+		t.Fatalf("should start with two decks: %v", err)
+	}
+	numCards, numJokers := 0, 0
+	for _, player := range game.Players {
+		if !(10 <= len(player.Cards) && len(player.Cards) <= 11) {
+			t.Errorf("num of cards should be either 10 or 11: %d", len(player.Cards))
+		}
+		numCards += len(player.Cards)
+		numJokers += countJokers(player.Cards)
+	}
+	if numCards != 108 || numJokers != 4 {
+		t.Errorf("two decks should have 108 cards and 4 jokers: %d, %d", numCards, numJokers)
+	}
+	if err := game.CheckInvariants(); err != nil {
+		t.Errorf("invariant is broken: %v", err)
+	}
+	game.AddPlayer("p11")
+	if err := game.Start(); !errors.Is(err, ErrTooManyPlayers) {
+		t.Errorf("%d players are too many: %v", MaxPlayersWithTwoDecks+1, err)
+	}
+}
+
+func Test_duplicateCards(t *testing.T) {
+	game := NewGame(Options{Decks: 2})
+	pair := []Card{NewCard(3, Spade), NewCard(3, Spade)}
+	if err := game.CanSubmit(pair); err != nil {
+		t.Errorf("duplicates should be a pair: %v", err)
+	}
+	if err := game.CanSubmit(append(slices.Clone(pair), declareJoker(-3, 3, Spade))); !errors.Is(err, ErrInvalidJoker) {
+		t.Errorf("a joker cannot be a third 3S: %v", err)
+	}
+	if err := NewGame(Options{}).CanSubmit(pair); !errors.Is(err, ErrInvalidJoker) {
+		t.Errorf("duplicates are impossible with a deck: %v", err)
+	}
+	quad := []Card{NewCard(3, Spade), NewCard(3, Spade), NewCard(3, Heart), NewCard(3, Heart)}
+	if !game.EffectsOf(quad).Kakumei {
+		t.Errorf("duplicates should count for Kakumei")
+	}
+
+	player := &Player{Cards: []Card{NewCard(3, Spade), NewCard(4, Heart), NewCard(3, Spade)}}
+	if player.hasCards([]Card{NewCard(3, Spade), NewCard(3, Spade), NewCard(3, Spade)}) {
+		t.Errorf("each duplicate can be used only once")
+	}
+	player.removeCards([]Card{NewCard(3, Spade)})
+	if len(player.Cards) != 2 || !player.hasCards([]Card{NewCard(3, Spade)}) {
+		t.Errorf("only one of the duplicates should be removed: %v", player.Cards)
+	}
+}
+
+func Test_decideRole(t *testing.T) {
+	for totalPlayers, want := range map[int][]PlayerRole{
+		2: {Daifugo, Daihinmin},
+		3: {Daifugo, Heimin, Daihinmin},
+		4: {Daifugo, Fugo, Hinmin, Daihinmin},
+		6: {Daifugo, Fugo, Heimin, Heimin, Hinmin, Daihinmin},
+		8: {Daifugo, Fugo, Heimin, Heimin, Heimin, Heimin, Hinmin, Daihinmin},
+	} {
+		for rank := 1; rank <= totalPlayers; rank++ {
+			if got := decideRole(rank, totalPlayers); got != want[rank-1] {
+				t.Errorf("decideRole(%d, %d) = %s, want %s", rank, totalPlayers, got, want[rank-1])
+			}
+		}
+	}
+}
+
+func Test_teams(t *testing.T) {
+	game := NewGame(Options{Teams: true, FixedSeats: true, PartnerPassCards: 2})
+	for _, playerName := range []string{"p1", "p2", "p3"} {
+		game.AddPlayer(playerName)
+	}
+	if err := game.Start(); !errors.Is(err, ErrInvalidTeams) {
+		t.Errorf("3 players cannot make teams: %v", err)
+	}
+	game.AddPlayer("p4")
+	if err := game.Start(); err != nil {
+		t.Fatalf("should start: %v", err)
+	}
+	if game.GameState != PassingToPartner || !game.InProgress() {
+		t.Fatalf("players should pass cards first: %s", game.GameState)
+	}
+	p1, p3 := game.Player("p1"), game.Player("p3")
+	if p1.Team != 1 || game.Player("p2").Team != 2 || game.Partner(p1) != p3 {
+		t.Errorf("partners should sit opposite: %+v", game.PublicPlayers())
+	}
+	if _, err := game.Submit("p1", p1.Cards[:1]); !errors.Is(err, ErrGameNotInProgress) {
+		t.Errorf("cards cannot be submitted while passing: %v", err)
+	}
+
+	if err := game.PassToPartner("p1", p1.Cards[:3]); !errors.Is(err, ErrTooManyCards) {
+		t.Errorf("only 2 cards can be passed: %v", err)
+	}
+	passed := slices.Clone(p1.Cards[:2])
+	numCards := len(p3.Cards)
+	if err := game.PassToPartner("p1", passed); err != nil {
+		t.Fatalf("should pass: %v", err)
+	}
+	if err := game.PassToPartner("p1", nil); !errors.Is(err, ErrAlreadyPassedToPartner) {
+		t.Errorf("cards can be passed only once: %v", err)
+	}
+	if view := game.ViewFor("p1"); view.PartnerPassCards != 0 {
+		t.Errorf("p1 has already passed: %+v", view)
+	}
+	for _, playerName := range []string{"p2", "p3", "p4"} {
+		if err := game.PassToPartner(playerName, nil); err != nil {
+			t.Fatalf("%s should pass nothing: %v", playerName, err)
+		}
+	}
+	if game.GameState != PlayingCards || len(p3.Cards) != numCards+2 || !p3.hasCards(passed) {
+		t.Errorf("cards should be exchanged: %s %v", game.GameState, p3.Cards)
+	}
+	if err := game.CheckInvariants(); err != nil {
+		t.Errorf("invariant is broken: %v", err)
+	}
+	if err := game.PassToPartner("p2", nil); !errors.Is(err, ErrNotPassingToPartner) {
+		t.Errorf("cards cannot be passed after the start: %v", err)
+	}
+}
+
+func Test_rankTeams(t *testing.T) {
+	game := NewGame(Options{Teams: true, FixedSeats: true})
+	for _, playerName := range []string{"p1", "p2", "p3", "p4"} {
+		game.AddPlayer(playerName)
+	}
+	game.Start()
+	for _, tt := range []struct {
+		playersByRank []string
+		want []int
+	}{
+		{[]string{"p1", "p2", "p4", "p3"}, []int{1, 2}},
+		{[]string{"p2", "p1", "p3", "p4"}, []int{2, 1}},
+		{[]string{"p1", "p3", "p2", "p4"}, []int{1, 2}},
+		{[]string{"p2", "p1", "p4", "p3"}, []int{2, 1}},
+	} {
+		if got := game.rankTeams(tt.playersByRank); !slices.Equal(got, tt.want) {
+			t.Errorf("rankTeams(%v) = %v, want %v", tt.playersByRank, got, tt.want)
+		}
+	}
+}
+
+func Test_Clone(t *testing.T) {
+	game := NewGame(Options{FixedSeats: true})
+	game.AddPlayer("p1")
+	game.AddPlayer("p2")
+	game.Start()
+	before := game.Clone()
+	player := game.CurrentPlayer()
+	if _, err := game.Submit(player.Name, player.Cards[:1]); err != nil {
+		t.Fatalf("should submit: %v", err)
+	}
+	game.PlayersByRank = append(game.PlayersByRank, "p2")
+	if len(before.PlayingCards) != 0 || len(before.Player(player.Name).Cards) != len(player.Cards)+1 ||
+		len(before.PlayersByRank) != 0 || before.CurrentPlayer().Name != player.Name {
+		t.Errorf("clone should not be changed by the game: %+v", before.PublicState())
+	}
+	if err := before.CheckInvariants(); err != nil {
+		t.Errorf("invariant of the clone is broken: %v", err)
+	}
+}
+
+func Test_pause(t *testing.T) {
+	game := NewGame(Options{FixedSeats: true})
+	game.AddPlayer("p1")
+	game.AddPlayer("p2")
+	if err := game.Pause(); !errors.Is(err, ErrGameNotInProgress) {
+		t.Errorf("a game not started cannot be paused: %v", err)
+	}
+	game.Start()
+	if err := game.Resume(); !errors.Is(err, ErrNotPaused) {
+		t.Errorf("a game not paused cannot be resumed: %v", err)
+	}
+	if err := game.Pause(); err != nil || game.GameState != Paused || !game.InProgress() {
+		t.Fatalf("should be paused: %v %s", err, game.GameState)
+	}
+	if err := game.Pause(); !errors.Is(err, ErrGamePaused) {
+		t.Errorf("a paused game cannot be paused again: %v", err)
+	}
+	player := game.CurrentPlayer()
+	if _, err := game.Submit(player.Name, player.Cards[:1]); !errors.Is(err, ErrGamePaused) {
+		t.Errorf("cards cannot be submitted while paused: %v", err)
+	}
+	if err := game.Pass(player.Name); !errors.Is(err, ErrGamePaused) {
+		t.Errorf("cannot pass while paused: %v", err)
+	}
+	if err := game.CheckInvariants(); err != nil {
+		t.Errorf("invariant is broken while paused: %v", err)
+	}
+	if err := game.Resume(); err != nil || game.GameState != PlayingCards || game.CurrentPlayer() != player {
+		t.Errorf("should resume from where it was paused: %v %s", err, game.GameState)
+	}
+}
+
+func Test_RemovePlayerInGame(t *testing.T) {
+	// the seed deals p1 a first card without effects, so the turn moves on after submitting it
+	game := NewGame(Options{FixedSeats: true, Rand: rand.New(rand.NewPCG(1, 0))})
+	for _, playerName := range []string{"p1", "p2", "p3", "p4"} {
+		game.AddPlayer(playerName)
+	}
+	game.Start()
+	numCards := len(game.Player("p2").Cards)
+	if err := game.RemovePlayer("p2"); err != nil {
+		t.Fatalf("should remove: %v", err)
+	}
+	if len(game.Players) != 3 || len(game.Trush) != numCards || game.CurrentPlayer().Name != "p1" {
+		t.Errorf("the hand should be trashed and the turn kept: %+v", game.PublicState())
+	}
+	if effects, err := game.Submit("p1", game.Player("p1").Cards[:1]); err != nil || effects != (SubmitEffects{}) {
+		t.Fatalf("should submit a card without effects: %+v %v", effects, err)
+	}
+	if game.CurrentPlayer().Name != "p3" || game.LastSubmittedTurn != 0 {
+		t.Errorf("the turn should skip the player who left: %+v", game.PublicState())
+	}
+	// p3 leaves on the turn. p4 takes it
+	game.RemovePlayer("p3")
+	if game.CurrentPlayer().Name != "p4" || game.LastSubmittedTurn != 0 {
+		t.Errorf("the next player should take the turn: %+v", game.PublicState())
+	}
+	if err := game.CheckInvariants(); err != nil {
+		t.Errorf("invariant is broken: %v", err)
+	}
+	game.RemovePlayer("p1")
+	if game.GameState != GameEnded {
+		t.Fatalf("the game should end with one player: %s", game.GameState)
+	}
+	if result := game.Results[0].PlayersByRank; !slices.Equal(result, []string{"p4", "p1", "p3", "p2"}) {
+		t.Errorf("players who left should be ranked last: %v", result)
+	}
+	if err := game.CheckInvariants(); err != nil {
+		t.Errorf("invariant is broken: %v", err)
+	}
+	game.AddPlayer("p5")
+	game.Start()
+	if game.Player("p4").Role != Daifugo {
+		t.Errorf("roles should be decided among the players seated: %+v", game.PublicPlayers())
+	}
+}
+
+func Test_RemovePlayerOwningField(t *testing.T) {
+	game := NewGame(Options{FixedSeats: true, Rand: rand.New(rand.NewPCG(1, 0))})
+	for _, playerName := range []string{"p1", "p2", "p3"} {
+		game.AddPlayer(playerName)
+	}
+	game.Start()
+	if effects, err := game.Submit("p1", game.Player("p1").Cards[:1]); err != nil || effects != (SubmitEffects{}) {
+		t.Fatalf("should submit a card without effects: %+v %v", effects, err)
+	}
+	game.Pass("p2")
+	game.RemovePlayer("p1")
+	if game.LastSubmittedTurn != -1 || game.CurrentPlayer().Name != "p3" {
+		t.Fatalf("nobody should own the field: %+v", game.PublicState())
+	}
+	game.Pass("p3")
+	if len(game.PlayingCards) != 0 || game.CurrentPlayer().Name != "p2" {
+		t.Errorf("the field should be flushed when all the others pass: %+v", game.PublicState())
+	}
+}
+
+func Test_RemovePlayerAfterPasses(t *testing.T) {
+	newGame := func() *Game {
+		game := NewGame(Options{FixedSeats: true, Rand: rand.New(rand.NewPCG(1, 0))})
+		for _, playerName := range []string{"p1", "p2", "p3", "p4"} {
+			game.AddPlayer(playerName)
+		}
+		game.Start()
+		if effects, err := game.Submit("p1", game.Player("p1").Cards[:1]); err != nil || effects != (SubmitEffects{}) {
+			t.Fatalf("should submit a card without effects: %+v %v", effects, err)
+		}
+		game.Pass("p2")
+		return game
+	}
+
+	// p4 has not passed, so the pass of p2 is still one of the two remaining players
+	game := newGame()
+	game.RemovePlayer("p4")
+	game.RemovePlayer("p1")
+	if game.PassCount != 1 || len(game.PlayingCards) != 1 || game.CurrentPlayer().Name != "p3" {
+		t.Fatalf("the field should stay until p3 passes: %+v", game.PublicState())
+	}
+	game.Pass("p3")
+	if len(game.PlayingCards) != 0 || game.CurrentPlayer().Name != "p2" {
+		t.Errorf("the field should be flushed when all the others pass: %+v", game.PublicState())
+	}
+
+	// the pass of p2, who leaves, does not count. p4 has not answered yet
+	game = newGame()
+	game.Pass("p3")
+	game.RemovePlayer("p1")
+	game.RemovePlayer("p2")
+	if game.PassCount != 1 || len(game.PlayingCards) != 1 || game.CurrentPlayer().Name != "p4" {
+		t.Fatalf("the field should stay until p4 passes: %+v", game.PublicState())
+	}
+	game.Pass("p4")
+	if len(game.PlayingCards) != 0 || game.CurrentPlayer().Name != "p3" {
+		t.Errorf("the field should be flushed when all the others pass: %+v", game.PublicState())
+	}
+	if err := game.CheckInvariants(); err != nil {
+		t.Errorf("invariant is broken: %v", err)
+	}
+}
+
+func Test_Abort(t *testing.T) {
+	game := NewGame(Options{FixedSeats: true})
+	game.AddPlayer("p1")
+	game.AddPlayer("p2")
+	if err := game.Abort(); !errors.Is(err, ErrGameNotInProgress) {
+		t.Errorf("a game not started cannot be aborted: %v", err)
+	}
+	game.Start()
+	game.Pause()
+	if err := game.Abort(); err != nil || game.GameState != WaitingForPlayers || len(game.Results) != 0 {
+		t.Errorf("should be aborted without a result: %v %s", err, game.GameState)
+	}
+	if err := game.CheckInvariants(); err != nil {
+		t.Errorf("invariant is broken: %v", err)
+	}
+	if err := game.Start(); err != nil {
+		t.Errorf("should start again: %v", err)
+	}
+}
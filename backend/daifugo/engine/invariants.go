@@ -0,0 +1,90 @@
+package engine
+
+import "fmt"
+
+// CheckInvariants returns an error if game breaks a rule which must always hold,
+// such as a card being lost or a finished player having the turn.
+// It is meant for tests and simulations.
+func (game *Game) CheckInvariants() error {
+	// every card of the deck is in exactly one of the hands, the field and the trash
+	deck := makeDeck(game.Decks)
+	counts := make(map[int]int, len(deck))
+	numCards := len(game.PlayingCards) + len(game.Trush)
+	for _, card := range game.PlayingCards {
+		counts[cardKey(card)]++
+	}
+	for _, card := range game.Trush {
+		counts[cardKey(card)]++
+	}
+	for _, player := range game.Players {
+		numCards += len(player.Cards)
+		for _, card := range player.Cards {
+			counts[cardKey(card)]++
+		}
+	}
+	if game.GameState == WaitingForPlayers {
+		if numCards != 0 {
+			return fmt.Errorf("%d cards are dealt before the game", numCards)
+		}
+		return nil
+	}
+	if numCards != len(deck) {
+		return fmt.Errorf("%d cards in the game, want %d", numCards, len(deck))
+	}
+	want := make(map[int]int, len(deck))
+	for _, card := range deck {
+		want[cardKey(card)]++
+	}
+	for _, card := range deck {
+		if counts[cardKey(card)] != want[cardKey(card)] {
+			return fmt.Errorf("%v appears %d times, want %d", card, counts[cardKey(card)], want[cardKey(card)])
+		}
+	}
+
+	seen := make(map[string]struct{})
+	for _, playerName := range game.PlayersByRank {
+		if _, ok := seen[playerName]; ok {
+			return fmt.Errorf("%s is ranked twice: %v", playerName, game.PlayersByRank)
+		}
+		seen[playerName] = struct{}{}
+	}
+	for _, player := range game.Players {
+		_, finished := seen[player.Name]
+		if finished != (len(player.Cards) == 0) {
+			return fmt.Errorf("%s has %d cards but finished is %v", player.Name, len(player.Cards), finished)
+		}
+	}
+
+	state := game.GameState
+	if state == Paused {
+		state = game.PausedState
+	}
+	if state != PlayingCards {
+		return nil
+	}
+	// exactly one player, who has not finished, has the turn
+	currentPlayer := game.CurrentPlayer()
+	if currentPlayer == nil {
+		return fmt.Errorf("turn %d is out of range", game.Turn)
+	}
+	if _, finished := seen[currentPlayer.Name]; finished {
+		return fmt.Errorf("finished player %s has the turn", currentPlayer.Name)
+	}
+	return nil
+}
+
+// cardKey identifies a physical card ignoring the declaration of Jokers, like SameCard.
+func cardKey(card Card) int {
+	suit := 0
+	switch card.CardType {
+	case Club:
+		suit = 1
+	case Spade:
+		suit = 2
+	case Heart:
+		suit = 3
+	case Diamond:
+		suit = 4
+	}
+	return card.Number*8 + suit
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SubmitEffects are the special rules triggered by submitting cards.
+type SubmitEffects struct {
+	// Kakumei flips the strength of the cards.
+	Kakumei bool
+	// Yagiri (an 8) and Spade3 (a Spade 3 on a Joker) flush the field.
+	Yagiri bool
+	Spade3 bool
+}
+
+// EffectsOf returns the effects which submitting cards to the current field triggers
+// under the special rules of the game.
+// Kakumei is triggered by 4 or more cards, in which duplicates of two decks count separately.
+func (game *Game) EffectsOf(submittingCards []Card) SubmitEffects {
+	var effects SubmitEffects
+	cards := resolveJokers(submittingCards)
+	if _, ok := game.SpecialRules[KakumeiRule]; ok && len(cards) >= 4 {
+		effects.Kakumei = true
+	}
+	if _, ok := game.SpecialRules[Yagiri]; ok {
+		effects.Yagiri = slices.ContainsFunc(cards, func(card Card) bool {
+			return card.Value == 8
+		})
+	}
+	if _, ok := game.SpecialRules[Spade3Rule]; ok {
+		effects.Spade3 = isSpade3Return(resolveJokers(game.TopFieldCards()), cards)
+	}
+	return effects
+}
+
+// isSpade3Return reports whether a single Spade 3 is submitted on a single Joker.
+func isSpade3Return(topFieldCards []Card, submittingCards []Card) bool {
+	return len(topFieldCards) == 1 && len(submittingCards) == 1 &&
+		topFieldCards[0].CardType == Joker &&
+		submittingCards[0].Number == 3 && submittingCards[0].CardType == Spade
+}
+
+func flipCardValue(cards []*Card) {
+	for _, card := range cards {
+		if card.CardType != Joker {
+			card.Value *= -1
+		}
+	}
+}
+
+// validateDeclarations checks the substitutes of Jokers in cards.
+// A Joker can substitute for a card of 1 to 13, and a card can appear in cards
+// at most as many times as in decks, including the substitutes.
+func validateDeclarations(cards []Card, decks int) error {
+	for _, card := range cards {
+		if card.CardType != Joker {
+			if card.AsNumber != 0 || card.AsCardType != "" {
+				return fmt.Errorf("%w: only jokers can substitute", ErrInvalidJoker)
+			}
+			continue
+		}
+		if !card.IsDeclared() {
+			if card.AsNumber != 0 {
+				return fmt.Errorf("%w: joker substitute has no card type", ErrInvalidJoker)
+			}
+			continue
+		}
+		if card.AsNumber < 1 || card.AsNumber > 13 || card.AsCardType == Joker {
+			return ErrInvalidJoker
+		}
+	}
+	counts := make(map[Card]int)
+	for _, card := range resolveJokers(cards) {
+		if card.CardType == Joker {
+			continue
+		}
+		counts[Card{Number: card.Number, CardType: card.CardType}]++
+		if counts[Card{Number: card.Number, CardType: card.CardType}] > decks {
+			return fmt.Errorf("%w: joker substitutes for a card in the same set", ErrInvalidJoker)
+		}
+	}
+	return nil
+}
+
+// CanSubmit checks whether submittingCards can be submitted to the current field.
+// It does not check the hand nor the turn.
+// TODO add special rule such as 縛り
+func (game *Game) CanSubmit(submittingCards []Card) error {
+	if len(submittingCards) == 0 {
+		return ErrNoCards
+	}
+
+	if err := validateDeclarations(submittingCards, game.Decks); err != nil {
+		return err
+	}
+
+	submitModes := game.SubmitModes
+	specialRules := game.SpecialRules
+	// declared Jokers are compared as the cards they substitute for
+	topFieldCards := resolveJokers(game.TopFieldCards())
+	submittingCards = resolveJokers(submittingCards)
+	_, isKakumei := submitModes[KakumeiMode]
+	if isKakumei {
+		cards := make([]*Card, len(topFieldCards)+len(submittingCards))
+		for i := 0; i < len(topFieldCards); i++ {
+			cards[i] = &topFieldCards[i]
+		}
+		for i := 0; i < len(submittingCards); i++ {
+			cards[i + len(topFieldCards)] = &submittingCards[i]
+		}
+		// the cards are copies, so they are not flipped back
+		flipCardValue(cards)
+	}
+
+	isAllSameValue := true
+	submitCardValue := JokerValue
+	for i := 0; i < len(submittingCards); i++ {
+		card1 := submittingCards[i]
+		if card1.CardType == Joker {
+			continue;
+		} else {
+			submitCardValue = card1.Value
+		}
+		for j := i+1; j < len(submittingCards); j++ {
+			card2 := submittingCards[j]
+			if card2.CardType == Joker {
+				continue;
+			}
+			if card1.Value != card2.Value {
+				isAllSameValue = false
+			}	
+		}
+	}
+	if !isAllSameValue {
+		return ErrMixedRanks
+	}
+	
+	if len(topFieldCards) == 0 {
+		return nil
+	}
+	if len(topFieldCards) != len(submittingCards) {
+		return ErrCountMismatch
+	}
+
+	if _, isKaidan := submitModes[KaidanMode]; isKaidan {
+		panic("KaidanMode is not implemented")
+	}
+
+	// _, isShibari := submitModes[ShibariMode]
+	if _, isSpade3 := specialRules[Spade3Rule]; isSpade3 {
+		if isSpade3Return(topFieldCards, submittingCards) {
+			return nil
+		}
+	}
+
+	
+	currentValue := JokerValue
+	for _, card := range topFieldCards {
+		if currentValue > card.Value {
+			currentValue = card.Value
+		}
+	}
+
+	if currentValue < submitCardValue {
+		return nil
+	}
+	return ErrTooWeak
+}
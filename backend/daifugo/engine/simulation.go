@@ -0,0 +1,293 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+)
+
+// Upper bound of the actions in a simulated game. A game which does not end by then is regarded as a livelock.
+const maxSimulationActions = 10000
+
+// Strategy decides the action of a bot.
+type Strategy string
+const (
+	// RandomStrategy chooses one of the legal moves or passing at random.
+	RandomStrategy Strategy = "random"
+	// WeakestStrategy submits the weakest legal move with the fewest Jokers.
+	WeakestStrategy Strategy = "weakest"
+	// StrongestStrategy submits the strongest legal move.
+	StrongestStrategy Strategy = "strongest"
+	// LargestStrategy submits the legal move with the most cards, the weakest of them.
+	LargestStrategy Strategy = "largest"
+)
+
+var Strategies = []Strategy{RandomStrategy, WeakestStrategy, StrongestStrategy, LargestStrategy}
+
+// SimulationOptions configures Simulate.
+type SimulationOptions struct {
+	NumGames int `json:"numGames"`
+	NumPlayers int `json:"numPlayers"`
+	// Strategies are assigned to the seats in order, and repeated if they are fewer than the seats.
+	Strategies []Strategy `json:"strategies"`
+	SpecialRules []SpecialRule `json:"specialRules"`
+	// Decks is the num of decks combined. 1 is used if it is zero.
+	Decks int `json:"decks,omitempty"`
+	Seed uint64 `json:"seed"`
+}
+
+// GameRecord is the result of a simulated game. Slices are indexed by seat.
+type GameRecord struct {
+	Game int `json:"game"`
+	NumActions int `json:"numActions"`
+	WinnerSeat int `json:"winnerSeat"`
+	// Roles are the roles at the start of the game, decided by the previous game.
+	Roles []PlayerRole `json:"roles"`
+	// Ranks start from 1.
+	Ranks []int `json:"ranks"`
+	Kakumei int `json:"kakumei"`
+	Yagiri int `json:"yagiri"`
+	Spade3 int `json:"spade3"`
+}
+
+// SimulationReport summarizes the simulated games.
+// A win rate is the number of wins divided by the number of games played in the seat, role or strategy.
+type SimulationReport struct {
+	Options SimulationOptions `json:"options"`
+	AverageActions float64 `json:"averageActions"`
+	WinRateBySeat []float64 `json:"winRateBySeat"`
+	WinRateByRole map[PlayerRole]float64 `json:"winRateByRole"`
+	WinRateByStrategy map[Strategy]float64 `json:"winRateByStrategy"`
+	// Average number of the events per game.
+	KakumeiPerGame float64 `json:"kakumeiPerGame"`
+	YagiriPerGame float64 `json:"yagiriPerGame"`
+	Spade3PerGame float64 `json:"spade3PerGame"`
+	Records []GameRecord `json:"records"`
+}
+
+// moveStrength returns the strength of move compared by CanSubmit.
+// A set of only Jokers is the strongest.
+func (game *Game) moveStrength(move []Card) int {
+	strength := JokerValue
+	for _, card := range resolveJokers(move) {
+		if card.CardType != Joker {
+			strength = card.Value
+		}
+	}
+	if _, isKakumei := game.SubmitModes[KakumeiMode]; isKakumei && strength != JokerValue {
+		strength = -strength
+	}
+	return strength
+}
+
+// choose returns the move of the current player, or nil to pass.
+// A bot always submits cards when the field is empty.
+func (strategy Strategy) choose(game *Game, rng *rand.Rand) []Card {
+	moves := game.LegalMoves(game.CurrentPlayer().Name)
+	if len(moves) == 0 {
+		return nil
+	}
+	mustSubmit := len(game.TopFieldCards()) == 0
+	switch strategy {
+	case WeakestStrategy, LargestStrategy:
+		return slices.MinFunc(moves, func(a, b []Card) int {
+			if strategy == LargestStrategy && len(a) != len(b) {
+				return len(b) - len(a)
+			}
+			if strengthA, strengthB := game.moveStrength(a), game.moveStrength(b); strengthA != strengthB {
+				return strengthA - strengthB
+			}
+			return countJokers(a) - countJokers(b)
+		})
+	case StrongestStrategy:
+		return slices.MaxFunc(moves, func(a, b []Card) int {
+			return game.moveStrength(a) - game.moveStrength(b)
+		})
+	default:
+		choice := rng.IntN(len(moves) + 1)
+		if mustSubmit {
+			choice = rng.IntN(len(moves))
+		}
+		if choice == len(moves) {
+			return nil
+		}
+		return moves[choice]
+	}
+}
+
+// BotMove returns the move of the current player chosen by strategy, or nil to pass.
+// It is for a bot playing in place of a player who has left.
+func (game *Game) BotMove(strategy Strategy) []Card {
+	if game.GameState != PlayingCards || game.CurrentPlayer() == nil {
+		return nil
+	}
+	rng := game.rng
+	if rng == nil {
+		rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return strategy.choose(game, rng)
+}
+
+// playBotAction plays an action of the current player by strategy.
+func (game *Game) playBotAction(strategy Strategy, rng *rand.Rand) (SubmitEffects, error) {
+	player := game.CurrentPlayer()
+	move := strategy.choose(game, rng)
+	if move == nil {
+		if len(game.TopFieldCards()) == 0 {
+			return SubmitEffects{}, fmt.Errorf("%s cannot submit any of %v to the empty field", player.Name, player.Cards)
+		}
+		game.pass()
+		return SubmitEffects{}, nil
+	}
+	effects, err := game.Submit(player.Name, move)
+	if err != nil {
+		return SubmitEffects{}, fmt.Errorf("legal move %v of %s is rejected: %w", move, player.Name, err)
+	}
+	return effects, nil
+}
+
+func (options SimulationOptions) validate() error {
+	if options.NumGames <= 0 {
+		return errors.New("num of games must be positive")
+	}
+	if options.Decks < 0 || options.Decks > MaxDecks {
+		return fmt.Errorf("num of decks must be between 1 and %d", MaxDecks)
+	}
+	if maxPlayers := MaxPlayersFor(options.Decks); options.NumPlayers < MinPlayers || options.NumPlayers > maxPlayers {
+		return fmt.Errorf("num of players must be between %d and %d", MinPlayers, maxPlayers)
+	}
+	if len(options.Strategies) == 0 {
+		return errors.New("no strategies")
+	}
+	for _, strategy := range options.Strategies {
+		if !slices.Contains(Strategies, strategy) {
+			return fmt.Errorf("unknown strategy: %s", strategy)
+		}
+	}
+	for _, rule := range options.SpecialRules {
+		if _, ok := StandardRule[rule]; !ok {
+			return fmt.Errorf("unknown rule: %s", rule)
+		}
+		if slices.Contains(UnimplementedRules, rule) {
+			return fmt.Errorf("rule not implemented: %s", rule)
+		}
+	}
+	return nil
+}
+
+// Simulate plays options.NumGames games in a row between bots and reports the results.
+// The seats are fixed, and the roles of a game are decided by the previous one.
+// The same options always produce the same report.
+func Simulate(options SimulationOptions) (SimulationReport, error) {
+	if err := options.validate(); err != nil {
+		return SimulationReport{}, err
+	}
+	rng := rand.New(rand.NewPCG(options.Seed, 0))
+	specialRules := make(map[SpecialRule]struct{})
+	for _, rule := range options.SpecialRules {
+		specialRules[rule] = struct{}{}
+	}
+	game := NewGame(Options{SpecialRules: specialRules, FixedSeats: true, Decks: options.Decks, Rand: rng})
+	strategies := make([]Strategy, options.NumPlayers)
+	for seat := range options.NumPlayers {
+		game.AddPlayer(fmt.Sprintf("seat%d", seat+1))
+		strategies[seat] = options.Strategies[seat%len(options.Strategies)]
+	}
+
+	records := make([]GameRecord, 0, options.NumGames)
+	for i := range options.NumGames {
+		record, err := game.simulateGame(strategies, rng)
+		if err != nil {
+			return SimulationReport{}, fmt.Errorf("game %d: %w", i+1, err)
+		}
+		record.Game = i + 1
+		records = append(records, record)
+	}
+	return summarizeRecords(options, strategies, records), nil
+}
+
+func (game *Game) simulateGame(strategies []Strategy, rng *rand.Rand) (GameRecord, error) {
+	if err := game.Start(); err != nil {
+		return GameRecord{}, err
+	}
+	record := GameRecord{
+		Roles: make([]PlayerRole, len(game.Players)),
+		Ranks: make([]int, len(game.Players)),
+	}
+	for seat, player := range game.Players {
+		record.Roles[seat] = player.Role
+	}
+	for game.GameState == PlayingCards {
+		if record.NumActions == maxSimulationActions {
+			return record, fmt.Errorf("game did not end in %d actions", maxSimulationActions)
+		}
+		record.NumActions++
+		effects, err := game.playBotAction(strategies[game.Turn], rng)
+		if err != nil {
+			return record, err
+		}
+		if effects.Kakumei {
+			record.Kakumei++
+		}
+		if effects.Yagiri {
+			record.Yagiri++
+		}
+		if effects.Spade3 {
+			record.Spade3++
+		}
+	}
+	result := game.Results[len(game.Results)-1]
+	for rank, playerName := range result.PlayersByRank {
+		seat := slices.IndexFunc(game.Players, func(player *Player) bool {
+			return player.Name == playerName
+		})
+		record.Ranks[seat] = rank + 1
+		if rank == 0 {
+			record.WinnerSeat = seat
+		}
+	}
+	return record, nil
+}
+
+func summarizeRecords(options SimulationOptions, strategies []Strategy, records []GameRecord) SimulationReport {
+	report := SimulationReport{
+		Options: options,
+		WinRateBySeat: make([]float64, options.NumPlayers),
+		WinRateByRole: make(map[PlayerRole]float64),
+		WinRateByStrategy: make(map[Strategy]float64),
+		Records: records,
+	}
+	gamesByRole := make(map[PlayerRole]int)
+	winsByRole := make(map[PlayerRole]int)
+	gamesByStrategy := make(map[Strategy]int)
+	winsByStrategy := make(map[Strategy]int)
+	for _, record := range records {
+		report.AverageActions += float64(record.NumActions)
+		report.KakumeiPerGame += float64(record.Kakumei)
+		report.YagiriPerGame += float64(record.Yagiri)
+		report.Spade3PerGame += float64(record.Spade3)
+		for seat, role := range record.Roles {
+			gamesByRole[role]++
+			gamesByStrategy[strategies[seat]]++
+		}
+		report.WinRateBySeat[record.WinnerSeat]++
+		winsByRole[record.Roles[record.WinnerSeat]]++
+		winsByStrategy[strategies[record.WinnerSeat]]++
+	}
+	numGames := float64(len(records))
+	report.AverageActions /= numGames
+	report.KakumeiPerGame /= numGames
+	report.YagiriPerGame /= numGames
+	report.Spade3PerGame /= numGames
+	for seat := range report.WinRateBySeat {
+		report.WinRateBySeat[seat] /= numGames
+	}
+	for role, games := range gamesByRole {
+		report.WinRateByRole[role] = float64(winsByRole[role]) / float64(games)
+	}
+	for strategy, games := range gamesByStrategy {
+		report.WinRateByStrategy[strategy] = float64(winsByStrategy[strategy]) / float64(games)
+	}
+	return report
+}
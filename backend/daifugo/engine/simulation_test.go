@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// simulateRandomGame plays a game between random bots, checking the invariants after each action.
+func simulateRandomGame(rng *rand.Rand, numPlayers int, decks int) (*Game, error) {
+	game := NewGame(Options{Decks: decks, Rand: rng})
+	for i := range numPlayers {
+		game.AddPlayer(fmt.Sprintf("p%d", i+1))
+	}
+	if err := game.Start(); err != nil {
+		return game, err
+	}
+	for range maxSimulationActions {
+		if err := game.CheckInvariants(); err != nil {
+			return game, err
+		}
+		if game.GameState != PlayingCards {
+			return game, nil
+		}
+		if _, err := game.playBotAction(RandomStrategy, rng); err != nil {
+			return game, err
+		}
+	}
+	return game, fmt.Errorf("game did not end in %d actions", maxSimulationActions)
+}
+
+func Test_simulateRandomGames(t *testing.T) {
+	numGames := 2000
+	if testing.Short() {
+		numGames = 200
+	}
+	for seed := range uint64(numGames) {
+		// every other game is played with two decks
+		decks := 1 + int(seed)%2
+		maxPlayers := MaxPlayersFor(decks)
+		numPlayers := MinPlayers + int(seed/2)%(maxPlayers-MinPlayers+1)
+		game, err := simulateRandomGame(rand.New(rand.NewPCG(seed, 0)), numPlayers, decks)
+		if err != nil {
+			t.Fatalf("seed %d with %d players and %d decks: %v\nfield: %v, trash: %d cards, ranks: %v",
+				seed, numPlayers, decks, err, game.PlayingCards, len(game.Trush), game.PlayersByRank)
+		}
+		if len(game.PlayersByRank) != numPlayers-1 {
+			t.Errorf("seed %d: %d players finished, want %d", seed, len(game.PlayersByRank), numPlayers-1)
+		}
+	}
+}
+
+// Test_simulateRandomLeaves removes random players in the middle of random games,
+// including the current player, the owner of the field and the finished players.
+func Test_simulateRandomLeaves(t *testing.T) {
+	numGames := 1000
+	if testing.Short() {
+		numGames = 100
+	}
+	for seed := range uint64(numGames) {
+		rng := rand.New(rand.NewPCG(seed, 1))
+		numPlayers := MinPlayers + int(seed)%(MaxPlayers-MinPlayers+1)
+		game := NewGame(Options{Rand: rng})
+		for i := range numPlayers {
+			game.AddPlayer(fmt.Sprintf("p%d", i+1))
+		}
+		game.Start()
+		for numActions := 0; game.GameState == PlayingCards; numActions++ {
+			if numActions == maxSimulationActions {
+				t.Fatalf("seed %d: game did not end in %d actions", seed, maxSimulationActions)
+			}
+			if rng.IntN(20) == 0 {
+				game.RemovePlayer(game.Players[rng.IntN(len(game.Players))].Name)
+			} else if _, err := game.playBotAction(RandomStrategy, rng); err != nil {
+				t.Fatalf("seed %d: %v", seed, err)
+			}
+			if err := game.CheckInvariants(); err != nil {
+				t.Fatalf("seed %d after %d actions: %v", seed, numActions, err)
+			}
+		}
+		if ranks := game.Results[0].PlayersByRank; len(ranks) != numPlayers {
+			t.Errorf("seed %d: %d players ranked, want %d: %v", seed, len(ranks), numPlayers, ranks)
+		}
+	}
+}
+
+func Test_advanceTurnTerminates(t *testing.T) {
+	game := NewGame(Options{FixedSeats: true})
+	game.AddPlayer("p1")
+	game.AddPlayer("p2")
+	game.Start()
+	game.PlayersByRank = []string{"p1", "p2"}
+	game.advanceTurn()
+	if game.Turn < 0 || game.Turn >= len(game.Players) {
+		t.Errorf("turn %d should stay in range", game.Turn)
+	}
+	game.Players = nil
+	game.advanceTurn()
+}
+
+func Test_simulationIsReproducible(t *testing.T) {
+	first, _ := simulateRandomGame(rand.New(rand.NewPCG(42, 0)), 4, 1)
+	second, _ := simulateRandomGame(rand.New(rand.NewPCG(42, 0)), 4, 1)
+	if !slices.Equal(first.PlayersByRank, second.PlayersByRank) || !slices.Equal(first.Trush, second.Trush) {
+		t.Errorf("the same seed must play the same game")
+	}
+}
+
+func Test_Simulate(t *testing.T) {
+	options := SimulationOptions{
+		NumGames: 50,
+		NumPlayers: 4,
+		Strategies: []Strategy{WeakestStrategy, StrongestStrategy, LargestStrategy, RandomStrategy},
+		SpecialRules: []SpecialRule{Yagiri, KakumeiRule},
+		Seed: 1,
+	}
+	report, err := Simulate(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Records) != options.NumGames {
+		t.Fatalf("%d records, want %d", len(report.Records), options.NumGames)
+	}
+	sum := 0.0
+	for _, rate := range report.WinRateBySeat {
+		sum += rate
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("win rates by seat must sum up to 1: %v", report.WinRateBySeat)
+	}
+	if report.Spade3PerGame != 0 {
+		t.Errorf("Spade3 must not happen without Spade3Rule: %v", report.Spade3PerGame)
+	}
+	if report.Records[0].Roles[0] != Heimin || report.Records[1].Roles[report.Records[0].WinnerSeat] != Daifugo {
+		t.Errorf("roles must be decided by the previous game: %+v", report.Records[:2])
+	}
+	again, _ := Simulate(options)
+	if !reflect.DeepEqual(report, again) {
+		t.Errorf("the same options must produce the same report")
+	}
+
+	options.Strategies = []Strategy{"unknown"}
+	if _, err := Simulate(options); err == nil {
+		t.Errorf("unknown strategy must be rejected")
+	}
+	options.Strategies = []Strategy{RandomStrategy}
+	options.SpecialRules = []SpecialRule{ShibariRule}
+	if _, err := Simulate(options); err == nil {
+		t.Errorf("unimplemented rule must be rejected")
+	}
+}
@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"maps"
+	"slices"
+)
+
+// PublicPlayer is a player as seen by everyone. The hand is hidden.
+type PublicPlayer struct{
+	Name string `json:"name"`
+	NumHandCards int `json:"numHandCards"`
+	Role PlayerRole `json:"role"`
+	// Team is the team of the player from 1 in a team game, otherwise 0.
+	Team int `json:"team,omitempty"`
+}
+
+// PublicState is the state of the game everyone can see.
+type PublicState struct {
+	Players []PublicPlayer `json:"players"`
+	GameState GameState `json:"gameState"`
+	Turn int `json:"turn"`
+	SubmitModes []SubmitMode `json:"submitModes"`
+	SpecialRules []SpecialRule `json:"specialRules"`
+	TopFieldCards []Card `json:"topFieldCards"`
+	PlayersByRank []string `json:"playersByRank"`
+	// TeamsByRank is the team ranking of the last team game when it has ended.
+	TeamsByRank []int `json:"teamsByRank,omitempty"`
+}
+
+// PlayerView is the private state of the game for one player.
+type PlayerView struct {
+	PlayerName string `json:"playerName"`
+	HandCards []Card `json:"handCards"`
+	// LegalMoves lists the card sets the player can submit now.
+	// It is empty unless it is the player's turn.
+	LegalMoves [][]Card `json:"legalMoves"`
+	IsMyTurn bool `json:"isMyTurn"`
+	// PartnerPassCards is the max num of cards the player can pass to the partner now.
+	// It is 0 unless the player has yet to pass while PassingToPartner.
+	PartnerPassCards int `json:"partnerPassCards,omitempty"`
+}
+
+// PublicPlayers returns the seated players without their hands.
+func (game *Game) PublicPlayers() []PublicPlayer {
+	players := make([]PublicPlayer, len(game.Players))
+	for i, player := range game.Players {
+		players[i] = PublicPlayer{Name: player.Name, NumHandCards: len(player.Cards), Role: player.Role, Team: player.Team}
+	}
+	return players
+}
+
+// SortedSpecialRules returns the special rules in effect in a stable order.
+func (game *Game) SortedSpecialRules() []SpecialRule {
+	return sortedKeys(game.SpecialRules)
+}
+
+// sortedKeys returns the keys of set in order. It is never nil so that it is encoded as [].
+func sortedKeys[K ~string](set map[K]struct{}) []K {
+	keys := slices.AppendSeq(make([]K, 0, len(set)), maps.Keys(set))
+	slices.Sort(keys)
+	return keys
+}
+
+// PublicState returns the state of the game everyone can see.
+func (game *Game) PublicState() PublicState {
+	return PublicState{
+		Players: game.PublicPlayers(),
+		GameState: game.GameState,
+		Turn: game.Turn,
+		SubmitModes: sortedKeys(game.SubmitModes),
+		SpecialRules: game.SortedSpecialRules(),
+		TopFieldCards: game.TopFieldCards(),
+		PlayersByRank: game.PlayersByRank,
+		TeamsByRank: game.teamsByRank(),
+	}
+}
+
+// teamsByRank returns the team ranking of the last game if it is an ended team game.
+func (game *Game) teamsByRank() []int {
+	if game.GameState != GameEnded || len(game.Results) == 0 {
+		return nil
+	}
+	return game.Results[len(game.Results)-1].TeamsByRank
+}
+
+// ViewFor returns the private state of the game for playerName.
+// The hand is empty if the player is not seated.
+func (game *Game) ViewFor(playerName string) PlayerView {
+	view := PlayerView{
+		PlayerName: playerName,
+		HandCards: make([]Card, 0),
+		LegalMoves: make([][]Card, 0),
+	}
+	player := game.Player(playerName)
+	if player == nil {
+		return view
+	}
+	view.HandCards = slices.Clone(player.Cards)
+	if game.GameState == PlayingCards && player == game.CurrentPlayer() {
+		view.IsMyTurn = true
+		view.LegalMoves = game.LegalMoves(playerName)
+	}
+	if _, passed := game.PartnerPasses[playerName]; game.GameState == PassingToPartner && !passed {
+		view.PartnerPassCards = game.PartnerPassCards
+	}
+	return view
+}
+
+// LegalMoves returns the card sets playerName can submit now.
+// Only sets of the same value (with Jokers) are considered, since Kaidan is not implemented.
+// Sets which differ only in identical duplicates of two decks are returned once.
+func (game *Game) LegalMoves(playerName string) [][]Card {
+	moves := make([][]Card, 0)
+	player := game.Player(playerName)
+	if game.GameState != PlayingCards || player == nil || game.CurrentPlayer() != player {
+		return moves
+	}
+	jokers := make([]Card, 0)
+	cardsByValue := make(map[int][]Card)
+	for _, card := range player.Cards {
+		if card.CardType == Joker {
+			jokers = append(jokers, card)
+		} else {
+			cardsByValue[card.Value] = append(cardsByValue[card.Value], card)
+		}
+	}
+	candidates := make([][]Card, 0)
+	for _, value := range slices.Sorted(maps.Keys(cardsByValue)) {
+		cards := cardsByValue[value]
+		for mask := 1; mask < 1<<len(cards); mask++ {
+			if !isCanonicalMask(cards, mask) {
+				continue
+			}
+			subset := make([]Card, 0, len(cards))
+			for i, card := range cards {
+				if mask&(1<<i) != 0 {
+					subset = append(subset, card)
+				}
+			}
+			for numJokers := 0; numJokers <= len(jokers); numJokers++ {
+				candidates = append(candidates, append(slices.Clone(subset), jokers[:numJokers]...))
+			}
+		}
+	}
+	for numJokers := 1; numJokers <= len(jokers); numJokers++ {
+		candidates = append(candidates, slices.Clone(jokers[:numJokers]))
+	}
+	for _, candidate := range candidates {
+		if game.CanSubmit(candidate) == nil {
+			moves = append(moves, candidate)
+		}
+	}
+	return moves
+}
+
+// isCanonicalMask reports whether mask selects the first ones of identical duplicates in cards,
+// so that each subset of cards is selected by only one mask.
+func isCanonicalMask(cards []Card, mask int) bool {
+	for i := range cards {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if mask&(1<<j) == 0 && SameCard(cards[i], cards[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
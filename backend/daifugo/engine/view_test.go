@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"testing"
+)
+
+func Test_LegalMoves(t *testing.T) {
+	game := NewGame(Options{})
+	game.AddPlayer("p1")
+	game.AddPlayer("p2")
+	game.GameState = PlayingCards
+	game.Turn = 0
+	game.Players[0].Cards = []Card{NewCard(4, Spade), NewCard(4, Heart), NewCard(6, Club), NewCard(-1, Joker)}
+	game.Players[1].Cards = []Card{NewCard(5, Spade)}
+	game.PlayingCards = []Card{NewCard(5, Diamond)}
+	game.LastSubmittedNum = 1
+
+	moves := game.LegalMoves("p1")
+	// 6 and Joker alone are stronger than 5
+	if len(moves) != 2 {
+		t.Fatalf("unexpected legal moves: %+v", moves)
+	}
+	for _, move := range moves {
+		if err := game.CanSubmit(move); err != nil {
+			t.Errorf("%+v is not legal: %v", move, err)
+		}
+	}
+	if moves := game.LegalMoves("p2"); len(moves) != 0 {
+		t.Errorf("legal moves for the player not in turn: %+v", moves)
+	}
+
+	game.PlayingCards = []Card{}
+	game.LastSubmittedNum = 0
+	// 4, 4, 4_4, each with and without Joker, 6, 6_Joker and Joker
+	if moves := game.LegalMoves("p1"); len(moves) != 9 {
+		t.Errorf("unexpected legal moves on the empty field: %+v", moves)
+	}
+
+	game.Decks = 2
+	game.Players[0].Cards = []Card{NewCard(4, Spade), NewCard(4, Heart), NewCard(4, Spade)}
+	// 4S, 4H, 4S_4H, 4S_4S and 4S_4S_4H
+	if moves := game.LegalMoves("p1"); len(moves) != 5 {
+		t.Errorf("duplicates should not make the same move twice: %+v", moves)
+	}
+}
+
+func Test_ViewFor(t *testing.T) {
+	game := NewGame(Options{})
+	game.AddPlayer("p1")
+	game.AddPlayer("p2")
+	if err := game.Start(); err != nil {
+		t.Fatal(err)
+	}
+	current := game.CurrentPlayer().Name
+	for _, player := range game.Players {
+		view := game.ViewFor(player.Name)
+		if len(view.HandCards) != len(player.Cards) {
+			t.Errorf("%s has %d cards in the view, want %d", player.Name, len(view.HandCards), len(player.Cards))
+		}
+		if view.IsMyTurn != (player.Name == current) || view.IsMyTurn != (len(view.LegalMoves) > 0) {
+			t.Errorf("legal moves must be given only to the current player: %+v", view)
+		}
+	}
+	if view := game.ViewFor("watcher"); len(view.HandCards) != 0 || view.IsMyTurn {
+		t.Errorf("a player not seated must have no hand: %+v", view)
+	}
+}
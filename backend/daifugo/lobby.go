@@ -0,0 +1,102 @@
+package daifugo
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-playground/daifugo/engine"
+	"go-playground/daifugo/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRoomListLimit = 20
+	maxRoomListLimit = 100
+)
+
+type RoomListResponse struct {
+	Rooms []session.RoomSummary `json:"rooms"`
+	// NextCursor is empty when there are no more rooms.
+	NextCursor string `json:"nextCursor"`
+}
+
+// ListRoomsHandler returns the room summaries sorted by name.
+// Query parameters:
+//   - state: only rooms with this GameState
+//   - joinable: "true" or "false"
+//   - cursor: nextCursor of the previous page
+//   - limit: page size (default 20, max 100)
+//   - code: invite code to include the private room
+func ListRoomsHandler(ctx *gin.Context) {
+	state := engine.GameState(ctx.Query("state"))
+	joinableQuery := ctx.Query("joinable")
+	cursor := ctx.Query("cursor")
+	limit := defaultRoomListLimit
+	if limitQuery := ctx.Query("limit"); limitQuery != "" {
+		var err error
+		limit, err = strconv.Atoi(limitQuery)
+		if err != nil || limit <= 0 {
+			respondError(ctx, http.StatusBadRequest, session.InvalidParameterError("limit", limitQuery))
+			return
+		}
+		limit = min(limit, maxRoomListLimit)
+	}
+	if joinableQuery != "" && joinableQuery != "true" && joinableQuery != "false" {
+		respondError(ctx, http.StatusBadRequest, session.InvalidParameterError("joinable", joinableQuery))
+		return
+	}
+
+	response := RoomListResponse{Rooms: make([]session.RoomSummary, 0)}
+	for _, summary := range session.ListRoomSummaries(ctx.Query("code")) {
+		if cursor != "" && summary.Name <= cursor {
+			continue
+		}
+		if state != "" && summary.GameState != state {
+			continue
+		}
+		if joinableQuery != "" && strconv.FormatBool(summary.Joinable) != joinableQuery {
+			continue
+		}
+		if len(response.Rooms) == limit {
+			response.NextCursor = response.Rooms[limit-1].Name
+			break
+		}
+		response.Rooms = append(response.Rooms, summary)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// LobbyEventsHandler pushes room list changes by SSE.
+// It sends all public rooms as a "snapshot" event first, then each change as a "room" event.
+func LobbyEventsHandler(ctx *gin.Context) {
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+
+	snapshot, events := session.SubscribeLobby()
+	defer session.UnsubscribeLobby(events)
+
+	ctx.SSEvent("snapshot", snapshot)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// too slow to receive events. the client will reconnect
+				return
+			}
+			ctx.SSEvent("room", event)
+			ctx.Writer.Flush()
+		case <-heartbeat.C:
+			ctx.SSEvent("heartbeat", "keep-alive")
+			ctx.Writer.Flush()
+		case <-ctx.Request.Context().Done():
+			return
+		}
+	}
+}
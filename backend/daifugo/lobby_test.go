@@ -0,0 +1,126 @@
+package daifugo
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go-playground/daifugo/engine"
+	"go-playground/daifugo/session"
+)
+
+func getRoomList(t *testing.T, url string) RoomListResponse {
+	t.Helper()
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	defer response.Body.Close()
+	var list RoomListResponse
+	json.NewDecoder(response.Body).Decode(&list)
+	return list
+}
+
+func Test_ListRoomsHandler(t *testing.T) {
+	server := startTestServer(t)
+	for _, roomName := range []string{"a", "b", "c", "d"} {
+		session.CreateRoom(roomName, "", session.RoomOptions{MaxPlayers: 2})
+	}
+	p1 := dialPlayer(t, server, "b", "p1")
+	readUntil(t, p1, "ADD_PLAYER")
+	p2 := dialPlayer(t, server, "b", "p2")
+	readUntil(t, p2, "ADD_PLAYER")
+	spectator := dialPlayer(t, server, "c", "s1?spectator=true")
+	readUntil(t, spectator, "ADD_PLAYER")
+
+	list := getRoomList(t, server.URL + "/daifugo/rooms?limit=3")
+	if len(list.Rooms) != 3 || list.Rooms[0].Name != "a" || list.NextCursor != "c" {
+		t.Fatalf("unexpected first page: %+v", list)
+	}
+	if list.Rooms[1].NumPlayers != 2 || list.Rooms[1].Joinable {
+		t.Errorf("room b should be full: %+v", list.Rooms[1])
+	}
+	if list.Rooms[2].NumSpectators != 1 || list.Rooms[2].NumPlayers != 0 {
+		t.Errorf("room c should have a spectator: %+v", list.Rooms[2])
+	}
+	if len(list.Rooms[0].SpecialRules) != len(engine.StandardRule) {
+		t.Errorf("special rules should not contain empty entries: %v", list.Rooms[0].SpecialRules)
+	}
+
+	list = getRoomList(t, server.URL + "/daifugo/rooms?limit=3&cursor=" + list.NextCursor)
+	if len(list.Rooms) != 1 || list.Rooms[0].Name != "d" || list.NextCursor != "" {
+		t.Errorf("unexpected second page: %+v", list)
+	}
+
+	list = getRoomList(t, server.URL + "/daifugo/rooms?joinable=false")
+	if len(list.Rooms) != 1 || list.Rooms[0].Name != "b" {
+		t.Errorf("only b should be unjoinable: %+v", list)
+	}
+
+	sendMessage(t, p1, `{"type": "GAME_START", "data": {"force": true}}`)
+	readUntil(t, p1, "GAME_START")
+	list = getRoomList(t, server.URL + "/daifugo/rooms?state=PlayingCards")
+	if len(list.Rooms) != 1 || list.Rooms[0].Name != "b" || list.Rooms[0].Status != session.RoomStatusPlaying {
+		t.Errorf("only b should be playing: %+v", list)
+	}
+}
+
+func Test_LobbyEventsHandler(t *testing.T) {
+	server := startTestServer(t)
+	session.CreateRoom("existing", "", session.RoomOptions{})
+	response, err := http.Get(server.URL + "/daifugo/lobby/events")
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	defer response.Body.Close()
+	reader := bufio.NewReader(response.Body)
+	// readEvent returns the event name and data of the next event.
+	readEvent := func() (string, string) {
+		var event, data string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			if line == "" && event != "" {
+				return event, data
+			}
+			if name, ok := strings.CutPrefix(line, "event:"); ok {
+				event = name
+			}
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data = value
+			}
+		}
+	}
+
+	event, data := readEvent()
+	if event != "snapshot" || !strings.Contains(data, `"name":"existing"`) {
+		t.Fatalf("unexpected first event: %s %s", event, data)
+	}
+
+	// events of rooms closed by other tests may arrive, so skip them
+	readRoomEvent := func(roomName string) session.LobbyEvent {
+		for {
+			event, data := readEvent()
+			var lobbyEvent session.LobbyEvent
+			json.Unmarshal([]byte(data), &lobbyEvent)
+			if event == "room" && lobbyEvent.Room.Name == roomName {
+				return lobbyEvent
+			}
+		}
+	}
+
+	room := session.CreateRoom("new", "", session.RoomOptions{})
+	if lobbyEvent := readRoomEvent("new"); lobbyEvent.Type != session.RoomUpdated {
+		t.Errorf("unexpected event: %+v", lobbyEvent)
+	}
+	credentials, _ := room.Credentials()
+	room.CloseByOwner(credentials.OwnerToken)
+	if lobbyEvent := readRoomEvent("new"); lobbyEvent.Type != session.RoomRemoved {
+		t.Errorf("unexpected event: %+v", lobbyEvent)
+	}
+}
@@ -0,0 +1,59 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go-playground/daifugo/engine"
+)
+
+// parseSubmittedCards converts the cards of SUBMIT_CARDS, each of which is a CardStr or a Card object.
+// The Value of an object is ignored, so clients cannot forge it.
+// "JOKER" means any Joker, and is resolved to a Joker in hand which is not submitted explicitly.
+// The declared substitute is kept. The errors are those of the engine, such as engine.ErrInvalidCard.
+func parseSubmittedCards(rawCards []json.RawMessage, hand []engine.Card) ([]engine.Card, error) {
+	cards := make([]engine.Card, len(rawCards))
+	anyJokers := make([]int, 0)
+	for i, rawCard := range rawCards {
+		var cardStr engine.CardStr
+		if err := json.Unmarshal(rawCard, &cardStr); err == nil {
+			card, err := engine.ParseCard(cardStr)
+			if err != nil {
+				return nil, err
+			}
+			if jokerStr, _, _ := strings.Cut(cardStr, ":"); strings.EqualFold(jokerStr, "JOKER") {
+				anyJokers = append(anyJokers, i)
+			}
+			cards[i] = card
+			continue
+		}
+		var card engine.Card
+		if err := json.Unmarshal(rawCard, &card); err != nil {
+			return nil, fmt.Errorf("%w: %s", engine.ErrInvalidCard, rawCard)
+		}
+		card, err := engine.ParseCard(engine.FormatCard(card))
+		if err != nil {
+			return nil, err
+		}
+		cards[i] = card
+	}
+
+	// the identities of "JOKER" are decided after all explicit Jokers are known
+	for _, i := range anyJokers {
+		cards[i].Number = 0
+	}
+	for _, i := range anyJokers {
+		for _, card := range hand {
+			if card.CardType == engine.Joker && !slices.ContainsFunc(cards, func(c engine.Card) bool { return engine.SameCard(c, card) }) {
+				cards[i].Number = card.Number
+				break
+			}
+		}
+		if cards[i].Number == 0 {
+			return nil, fmt.Errorf("%w: no joker in hand", engine.ErrCardNotInHand)
+		}
+	}
+	return cards, nil
+}
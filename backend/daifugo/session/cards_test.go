@@ -0,0 +1,38 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"go-playground/daifugo/engine"
+)
+
+func Test_parseSubmittedCards(t *testing.T) {
+	hand := []engine.Card{engine.NewCard(3, engine.Spade), engine.NewCard(-2, engine.Joker), engine.NewCard(-1, engine.Joker)}
+	parse := func(request string) ([]engine.Card, error) {
+		var rawCards []json.RawMessage
+		if err := json.Unmarshal([]byte(request), &rawCards); err != nil {
+			t.Fatal(err)
+		}
+		return parseSubmittedCards(rawCards, hand)
+	}
+
+	// the forged value is ignored
+	cards, err := parse(`[{"number": 3, "value": 99, "cardType": "Spade"}, "3s"]`)
+	if err != nil || !slices.Equal(cards, []engine.Card{engine.NewCard(3, engine.Spade), engine.NewCard(3, engine.Spade)}) {
+		t.Errorf("unexpected cards: %v, %v", cards, err)
+	}
+	// "JOKER" is resolved to a Joker in hand which is not submitted explicitly
+	cards, err = parse(`["JOKER:3H", "JOKER2"]`)
+	if err != nil || !slices.Equal(cards, []engine.Card{engine.Card{Number: -1, Value: engine.JokerValue, CardType: engine.Joker, AsNumber: 3, AsCardType: engine.Heart}, engine.NewCard(-2, engine.Joker)}) {
+		t.Errorf("unexpected cards: %v, %v", cards, err)
+	}
+	if _, err := parse(`["JOKER", "JOKER", "JOKER"]`); !errors.Is(err, engine.ErrCardNotInHand) {
+		t.Errorf("jokers more than the hand must be rejected")
+	}
+	if _, err := parse(`[{"number": 14, "cardType": "Spade"}]`); !errors.Is(err, engine.ErrInvalidCard) {
+		t.Errorf("invalid card object must be rejected")
+	}
+}
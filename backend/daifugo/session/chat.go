@@ -0,0 +1,138 @@
+package session
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Number of messages kept per room and sent to the client on join.
+	chatHistorySize = 50
+	MaxChatLength = 200
+	// A player can send ChatRateLimit messages per chatRateWindow.
+	ChatRateLimit = 5
+	chatRateWindow = 10 * time.Second
+)
+
+// ChatChannel decides who can read a message.
+// Messages from players are read by everyone, but messages from spectators
+// are read only by spectators so that they cannot tell hands to the players.
+type ChatChannel string
+const (
+	PlayersChannel ChatChannel = "players"
+	SpectatorsChannel ChatChannel = "spectators"
+)
+
+// ChatStamps are the preset stamps. The client shows them as images or texts.
+var ChatStamps = []string{"GOOD", "NICE", "THANKS", "SORRY", "HURRY", "GG"}
+
+type ChatRequest struct {
+	// Either Text or Stamp must be set.
+	Text string `json:"text"`
+	Stamp string `json:"stamp"`
+}
+
+type ChatMessage struct {
+	PlayerName string `json:"playerName"`
+	Channel ChatChannel `json:"channel"`
+	Text string `json:"text,omitempty"`
+	Stamp string `json:"stamp,omitempty"`
+	SentAt time.Time `json:"sentAt"`
+}
+
+type ChatHistoryResponse struct {
+	Messages []ChatMessage `json:"messages"`
+}
+
+// chatLog holds the chat history and the send times for rate limiting.
+type chatLog struct {
+	history []ChatMessage
+	sentAt map[string][]time.Time
+}
+
+// allow reports whether playerName can send a message at now, and records it if so.
+func (chat *chatLog) allow(playerName string, now time.Time) bool {
+	if chat.sentAt == nil {
+		chat.sentAt = make(map[string][]time.Time)
+	}
+	recent := slices.DeleteFunc(chat.sentAt[playerName], func(sentAt time.Time) bool {
+		return now.Sub(sentAt) >= chatRateWindow
+	})
+	if len(recent) >= ChatRateLimit {
+		chat.sentAt[playerName] = recent
+		return false
+	}
+	chat.sentAt[playerName] = append(recent, now)
+	return true
+}
+
+func (chat *chatLog) add(message ChatMessage) {
+	chat.history = append(chat.history, message)
+	if len(chat.history) > chatHistorySize {
+		chat.history = slices.Clone(chat.history[len(chat.history)-chatHistorySize:])
+	}
+}
+
+// canRead reports whether client can read messages of channel.
+func (client *Client) canRead(channel ChatChannel) bool {
+	return channel == PlayersChannel || client.spectator
+}
+
+// chatHistoryFor returns the messages client can read.
+func (room *Room) chatHistoryFor(client *Client) ChatHistoryResponse {
+	messages := make([]ChatMessage, 0, len(room.chat.history))
+	for _, message := range room.chat.history {
+		if client.canRead(message.Channel) {
+			messages = append(messages, message)
+		}
+	}
+	return ChatHistoryResponse{Messages: messages}
+}
+
+func handleChat(room *Room, playerName string, data json.RawMessage) error {
+	var request ChatRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return newRequestError(InvalidMessage, textInvalidRequest)
+	}
+	client, ok := room.clients[playerName]
+	if !ok {
+		return newRequestError(PlayerNotFound, textPlayerNotFound)
+	}
+	request.Text = strings.TrimSpace(request.Text)
+	if (request.Text == "") == (request.Stamp == "") {
+		return newRequestError(InvalidMessage, textTextOrStamp)
+	}
+	if request.Stamp != "" && !slices.Contains(ChatStamps, request.Stamp) {
+		return newRequestError(InvalidMessage, textUnknownStamp, request.Stamp)
+	}
+	if utf8.RuneCountInString(request.Text) > MaxChatLength {
+		return newRequestError(MessageTooLong, textMessageTooLong)
+	}
+	now := time.Now()
+	if !room.chat.allow(playerName, now) {
+		return newRequestError(RateLimited, textRateLimited)
+	}
+
+	channel := PlayersChannel
+	if client.spectator {
+		channel = SpectatorsChannel
+	}
+	message := ChatMessage{
+		PlayerName: playerName,
+		Channel: channel,
+		Text: request.Text,
+		Stamp: request.Stamp,
+		SentAt: now,
+	}
+	room.chat.add(message)
+	response := encodeResponse("CHAT", message)
+	for _, client := range room.clients {
+		if client.canRead(channel) {
+			room.sendMessage(client, response)
+		}
+	}
+	return nil
+}
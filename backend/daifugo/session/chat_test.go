@@ -0,0 +1,39 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_chatLogAllow(t *testing.T) {
+	var chat chatLog
+	now := time.Now()
+	for i := 0; i < ChatRateLimit; i++ {
+		if !chat.allow("p1", now) {
+			t.Fatalf("message %d should be allowed", i)
+		}
+	}
+	if chat.allow("p1", now) {
+		t.Errorf("message over the limit should be rejected")
+	}
+	if !chat.allow("p2", now) {
+		t.Errorf("limit should be per player")
+	}
+	if !chat.allow("p1", now.Add(chatRateWindow)) {
+		t.Errorf("message after the window should be allowed")
+	}
+}
+
+func Test_chatLogHistorySize(t *testing.T) {
+	var chat chatLog
+	for i := 0; i < chatHistorySize+10; i++ {
+		chat.add(ChatMessage{Text: strings.Repeat("a", i+1)})
+	}
+	if len(chat.history) != chatHistorySize {
+		t.Errorf("history should be limited to %d but %d", chatHistorySize, len(chat.history))
+	}
+	if len(chat.history[0].Text) != 11 {
+		t.Errorf("oldest messages should be dropped")
+	}
+}
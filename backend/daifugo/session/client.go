@@ -0,0 +1,134 @@
+package session
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"go-playground/daifugo/engine"
+)
+
+// Number of outbound messages buffered per client.
+// A client whose buffer is full is regarded as too slow and disconnected,
+// so that it never blocks the other clients in the room.
+const sendBufferSize = 64
+
+// Client is a connection of a player or a spectator to a room.
+// The room queues the encoded responses to the client, and the transport
+// delivers them in order by reading Messages.
+type Client struct {
+	playerName string
+	// spectator can watch the game but cannot play.
+	spectator bool
+	// language is used to translate the texts sent to the client.
+	language engine.Language
+	// lastState is the last PLAYER_STATE sent to the client.
+	lastState []byte
+	send chan []byte
+	closeOnce sync.Once
+}
+
+func NewClient(playerName string, spectator bool, language engine.Language) *Client {
+	return &Client{
+		playerName: playerName,
+		spectator: spectator,
+		language: language,
+		send: make(chan []byte, sendBufferSize),
+	}
+}
+
+// Messages returns the outbound queue. It is closed when the client is
+// disconnected by the room, and the transport must close the connection then.
+func (client *Client) Messages() <-chan []byte {
+	return client.send
+}
+
+// enqueue adds message to the outbound queue without blocking.
+// It returns false when the queue is full.
+func (client *Client) enqueue(message []byte) bool {
+	select {
+	case client.send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close disconnects the client. The transport sees Messages closed.
+func (client *Client) Close() {
+	client.closeOnce.Do(func() {
+		close(client.send)
+	})
+}
+
+func encodeResponse(responseType string, data any) []byte {
+	dataBytes, _ := json.Marshal(data)
+	response, _ := json.Marshal(RawMessageResponse{
+		Type: responseType,
+		Data: dataBytes,
+	})
+	return response
+}
+
+// sendMessage queues message for client. A client whose queue overflows is
+// removed from the room and disconnected.
+func (room *Room) sendMessage(client *Client, message []byte) {
+	if client.enqueue(message) {
+		return
+	}
+	log.Printf("send buffer of %s is full. disconnecting", client.playerName)
+	room.removeClient(client)
+}
+
+// addClient registers client to the room. A previous connection of the same
+// player is disconnected.
+func (room *Room) addClient(client *Client) {
+	if previous, ok := room.clients[client.playerName]; ok {
+		previous.Close()
+	}
+	room.clients[client.playerName] = client
+	room.lastActiveAt = time.Now()
+}
+
+// removeClient unregisters client if it is still the current connection of the player.
+func (room *Room) removeClient(client *Client) {
+	if room.clients[client.playerName] == client {
+		delete(room.clients, client.playerName)
+		room.lastActiveAt = time.Now()
+	}
+	client.Close()
+}
+
+// broadcast sends a response to all clients in the room.
+func (room *Room) broadcast(responseType string, data any) {
+	message := encodeResponse(responseType, data)
+	for _, client := range room.clients {
+		room.sendMessage(client, message)
+	}
+}
+
+// sendToPlayer sends a response only to the client of playerName.
+func (room *Room) sendToPlayer(playerName string, responseType string, data any) {
+	client, ok := room.clients[playerName]
+	if !ok {
+		return
+	}
+	room.sendMessage(client, encodeResponse(responseType, data))
+}
+
+// broadcastText sends a MessageResponse of text to all clients in the room,
+// translated into the language of each client.
+func (room *Room) broadcastText(responseType string, text Text) {
+	for _, client := range room.clients {
+		room.sendMessage(client, encodeResponse(responseType, MessageResponse{Message: text.Message(client.language)}))
+	}
+}
+
+// languageOf returns the language of the client of playerName.
+func (room *Room) languageOf(playerName string) engine.Language {
+	if client, ok := room.clients[playerName]; ok {
+		return client.language
+	}
+	return DefaultLanguage
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"testing"
+
+	"go-playground/daifugo/engine"
+)
+
+func Test_broadcastDisconnectsSlowClient(t *testing.T) {
+	room := &Room{
+		clients: make(map[string]*Client),
+		game: engine.NewGame(engine.Options{}),
+	}
+	fast := NewClient("fast", false, DefaultLanguage)
+	slow := NewClient("slow", false, DefaultLanguage)
+	room.addClient(fast)
+	room.addClient(slow)
+	for i := 0; i < sendBufferSize; i++ {
+		slow.enqueue([]byte("pending"))
+	}
+
+	room.broadcast("MESSAGE", MessageResponse{Message: "hello"})
+
+	if _, ok := room.clients["slow"]; ok {
+		t.Errorf("slow client should be removed")
+	}
+	if _, ok := room.clients["fast"]; !ok {
+		t.Errorf("fast client should stay")
+	}
+	if len(fast.send) != 1 {
+		t.Errorf("fast client should receive the message")
+	}
+	for i := 0; i < sendBufferSize; i++ {
+		<-slow.send
+	}
+	if _, ok := <-slow.send; ok {
+		t.Errorf("slow client should be closed")
+	}
+}
+
+func Test_addClientReplacesPreviousConnection(t *testing.T) {
+	room := &Room{
+		clients: make(map[string]*Client),
+		game: engine.NewGame(engine.Options{}),
+	}
+	first := NewClient("p1", false, DefaultLanguage)
+	second := NewClient("p1", false, DefaultLanguage)
+	room.addClient(first)
+	room.addClient(second)
+	// cleanup of the old connection must not remove the new one
+	room.removeClient(first)
+	if room.clients["p1"] != second {
+		t.Errorf("second connection should stay")
+	}
+	if _, ok := <-first.send; ok {
+		t.Errorf("first connection should be closed")
+	}
+}
@@ -0,0 +1,237 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+
+	"go-playground/daifugo/engine"
+)
+
+// Number of game events kept per room to fill the gap of a client.
+// A client which has missed more events gets a snapshot instead.
+const gameEventBufferSize = 128
+
+type GameEventType string
+const (
+	CardsPlayed GameEventType = "CARDS_PLAYED"
+	Passed GameEventType = "PASSED"
+	FieldCleared GameEventType = "FIELD_CLEARED"
+	PlayerFinished GameEventType = "PLAYER_FINISHED"
+	ModeChanged GameEventType = "MODE_CHANGED"
+	TurnChanged GameEventType = "TURN_CHANGED"
+	GameStateChanged GameEventType = "GAME_STATE_CHANGED"
+)
+
+// GameEvent is a small change of the public game state.
+// Seq increases by one for each event and snapshot, so a client can detect a gap
+// and request the missing events with SYNC.
+type GameEvent struct {
+	Seq int `json:"seq"`
+	Type GameEventType `json:"type"`
+	// PlayerName is set for CARDS_PLAYED, PASSED and PLAYER_FINISHED.
+	PlayerName string `json:"playerName,omitempty"`
+	// Cards are the cards of CARDS_PLAYED.
+	Cards []engine.Card `json:"cards,omitempty"`
+	// Mode and Enabled are set for MODE_CHANGED.
+	Mode engine.SubmitMode `json:"mode,omitempty"`
+	Enabled bool `json:"enabled,omitempty"`
+	// Turn is set for TURN_CHANGED.
+	Turn int `json:"turn"`
+	// GameState is set for GAME_STATE_CHANGED.
+	GameState engine.GameState `json:"gameState,omitempty"`
+}
+
+// GameSnapshotResponse is the whole public game state at Seq.
+type GameSnapshotResponse struct {
+	Seq int `json:"seq"`
+	engine.PublicState
+}
+
+type SyncRequest struct {
+	// LastSeq is the last seq the client has applied.
+	LastSeq int `json:"lastSeq"`
+}
+
+// gameEventLog holds the recent events of a room.
+type gameEventLog struct {
+	seq int
+	events []GameEvent
+	// pending are the events recorded by the current command, which are not sent yet.
+	pending []GameEvent
+	// state is the public game state at seq.
+	state *engine.PublicState
+}
+
+// record adds an event caused by a player action.
+// Events caused by the engine, such as FIELD_CLEARED, are derived in syncGameEvents.
+func (eventLog *gameEventLog) record(event GameEvent) {
+	eventLog.pending = append(eventLog.pending, event)
+}
+
+// since returns the events after lastSeq, or false if some of them are no longer kept.
+func (eventLog *gameEventLog) since(lastSeq int) ([]GameEvent, bool) {
+	if lastSeq > eventLog.seq {
+		return nil, false
+	}
+	if lastSeq == eventLog.seq {
+		return []GameEvent{}, true
+	}
+	index := slices.IndexFunc(eventLog.events, func(event GameEvent) bool {
+		return event.Seq == lastSeq+1
+	})
+	if index < 0 {
+		return nil, false
+	}
+	return eventLog.events[index:], true
+}
+
+func (eventLog *gameEventLog) append(event GameEvent) GameEvent {
+	eventLog.seq++
+	event.Seq = eventLog.seq
+	eventLog.events = append(eventLog.events, event)
+	if len(eventLog.events) > gameEventBufferSize {
+		eventLog.events = slices.Clone(eventLog.events[len(eventLog.events)-gameEventBufferSize:])
+	}
+	return event
+}
+
+func cloneGameData(state engine.PublicState) engine.PublicState {
+	state.Players = slices.Clone(state.Players)
+	state.SubmitModes = slices.Clone(state.SubmitModes)
+	state.SpecialRules = slices.Clone(state.SpecialRules)
+	state.TopFieldCards = slices.Clone(state.TopFieldCards)
+	state.PlayersByRank = slices.Clone(state.PlayersByRank)
+	return state
+}
+
+// applyGameEvent updates state by event in the same way as the client does.
+func applyGameEvent(state *engine.PublicState, event GameEvent) {
+	switch event.Type {
+	case CardsPlayed:
+		for i, player := range state.Players {
+			if player.Name == event.PlayerName {
+				state.Players[i].NumHandCards -= len(event.Cards)
+			}
+		}
+		state.TopFieldCards = slices.Clone(event.Cards)
+	case FieldCleared:
+		state.TopFieldCards = []engine.Card{}
+	case PlayerFinished:
+		state.PlayersByRank = append(state.PlayersByRank, event.PlayerName)
+	case ModeChanged:
+		state.SubmitModes = slices.DeleteFunc(state.SubmitModes, func(mode engine.SubmitMode) bool {
+			return mode == event.Mode
+		})
+		if event.Enabled {
+			state.SubmitModes = append(state.SubmitModes, event.Mode)
+			slices.Sort(state.SubmitModes)
+		}
+	case TurnChanged:
+		state.Turn = event.Turn
+	case GameStateChanged:
+		state.GameState = event.GameState
+	}
+}
+
+// deriveGameEvents returns the events which change state into next, following the recorded ones.
+func deriveGameEvents(state engine.PublicState, recorded []GameEvent, next engine.PublicState) []GameEvent {
+	events := slices.Clone(recorded)
+	for _, event := range recorded {
+		applyGameEvent(&state, event)
+	}
+	derive := func(event GameEvent) {
+		applyGameEvent(&state, event)
+		events = append(events, event)
+	}
+	if len(state.TopFieldCards) > 0 && len(next.TopFieldCards) == 0 {
+		derive(GameEvent{Type: FieldCleared})
+	}
+	if len(next.PlayersByRank) > len(state.PlayersByRank) {
+		for _, playerName := range next.PlayersByRank[len(state.PlayersByRank):] {
+			derive(GameEvent{Type: PlayerFinished, PlayerName: playerName})
+		}
+	}
+	for _, mode := range state.SubmitModes {
+		if !slices.Contains(next.SubmitModes, mode) {
+			derive(GameEvent{Type: ModeChanged, Mode: mode, Enabled: false})
+		}
+	}
+	for _, mode := range next.SubmitModes {
+		if !slices.Contains(state.SubmitModes, mode) {
+			derive(GameEvent{Type: ModeChanged, Mode: mode, Enabled: true})
+		}
+	}
+	if state.Turn != next.Turn {
+		derive(GameEvent{Type: TurnChanged, Turn: next.Turn})
+	}
+	if state.GameState != next.GameState {
+		derive(GameEvent{Type: GameStateChanged, GameState: next.GameState})
+	}
+	return events
+}
+
+func sameGameData(a engine.PublicState, b engine.PublicState) bool {
+	aJSON, _ := json.Marshal(a)
+	bJSON, _ := json.Marshal(b)
+	return bytes.Equal(aJSON, bJSON)
+}
+
+// snapshot returns the current public game state.
+func (room *Room) snapshot() GameSnapshotResponse {
+	return GameSnapshotResponse{
+		Seq: room.gameEvents.seq,
+		PublicState: room.game.PublicState(),
+	}
+}
+
+// syncGameEvents broadcasts the changes of the public game state made by the last command.
+// The changes are sent as events if they can describe them, otherwise as a snapshot,
+// e.g. when a game starts or a player joins.
+func (room *Room) syncGameEvents() {
+	gameEvents := &room.gameEvents
+	recorded := gameEvents.pending
+	gameEvents.pending = nil
+	next := room.game.PublicState()
+	if gameEvents.state == nil {
+		gameEvents.state = &next
+		return
+	}
+	if len(recorded) == 0 && sameGameData(*gameEvents.state, next) {
+		return
+	}
+
+	events := deriveGameEvents(cloneGameData(*gameEvents.state), recorded, next)
+	expected := cloneGameData(*gameEvents.state)
+	for _, event := range events {
+		applyGameEvent(&expected, event)
+	}
+	gameEvents.state = &next
+	if !sameGameData(expected, next) {
+		// events cannot describe the change. a snapshot replaces all the previous events
+		gameEvents.seq++
+		gameEvents.events = nil
+		room.broadcast("GAME_SNAPSHOT", room.snapshot())
+		return
+	}
+	for _, event := range events {
+		room.broadcast("GAME_EVENT", gameEvents.append(event))
+	}
+}
+
+// handleSync sends the events after lastSeq to the client, or a snapshot if they are no longer kept.
+func handleSync(room *Room, playerName string, data json.RawMessage) error {
+	var request SyncRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return newRequestError(InvalidMessage, textInvalidRequest)
+	}
+	events, ok := room.gameEvents.since(request.LastSeq)
+	if !ok {
+		room.sendToPlayer(playerName, "GAME_SNAPSHOT", room.snapshot())
+		return nil
+	}
+	for _, event := range events {
+		room.sendToPlayer(playerName, "GAME_EVENT", event)
+	}
+	return nil
+}
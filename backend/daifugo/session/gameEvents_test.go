@@ -0,0 +1,58 @@
+package session
+
+import (
+	"testing"
+
+	"go-playground/daifugo/engine"
+)
+
+func Test_deriveGameEvents(t *testing.T) {
+	game := engine.NewGame(engine.Options{})
+	game.AddPlayer("p1")
+	game.AddPlayer("p2")
+	game.GameState = engine.PlayingCards
+	game.Players[0].Cards = []engine.Card{engine.NewCard(8, engine.Spade), engine.NewCard(5, engine.Heart)}
+	game.Players[1].Cards = []engine.Card{engine.NewCard(4, engine.Spade)}
+	before := game.PublicState()
+
+	// Yagiri clears the field and keeps the turn
+	submitted := []engine.Card{engine.NewCard(8, engine.Spade)}
+	if _, err := game.Submit("p1", submitted); err != nil {
+		t.Fatalf("cannot submit: %v", err)
+	}
+	after := game.PublicState()
+	events := deriveGameEvents(cloneGameData(before),
+		[]GameEvent{{Type: CardsPlayed, PlayerName: "p1", Cards: submitted}}, after)
+	types := make([]GameEventType, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	if len(types) != 2 || types[0] != CardsPlayed || types[1] != FieldCleared {
+		t.Errorf("unexpected events: %v", types)
+	}
+	for _, event := range events {
+		applyGameEvent(&before, event)
+	}
+	if !sameGameData(before, after) {
+		t.Errorf("events do not reproduce the state: got %+v, want %+v", before, after)
+	}
+}
+
+func Test_gameEventLogSince(t *testing.T) {
+	var eventLog gameEventLog
+	for range gameEventBufferSize + 10 {
+		eventLog.append(GameEvent{Type: Passed})
+	}
+	if events, ok := eventLog.since(eventLog.seq - 3); !ok || len(events) != 3 || events[0].Seq != eventLog.seq-2 {
+		t.Errorf("unexpected events: %+v, %v", events, ok)
+	}
+	if events, ok := eventLog.since(eventLog.seq); !ok || len(events) != 0 {
+		t.Errorf("unexpected events: %+v, %v", events, ok)
+	}
+	if _, ok := eventLog.since(1); ok {
+		t.Errorf("dropped events must not be returned")
+	}
+	if _, ok := eventLog.since(eventLog.seq + 1); ok {
+		t.Errorf("future events must not be returned")
+	}
+}
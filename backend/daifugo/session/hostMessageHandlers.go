@@ -0,0 +1,244 @@
+package session
+
+import (
+	"encoding/json"
+	"slices"
+
+	"go-playground/daifugo/engine"
+)
+
+// RoomStateResponse is broadcast when the host or the room settings change.
+type RoomStateResponse struct {
+	Host string `json:"host"`
+	Locked bool `json:"locked"`
+	PlayerNames []string `json:"playerNames"`
+	ReadyPlayerNames []string `json:"readyPlayerNames"`
+	SpecialRules []engine.SpecialRule `json:"specialRules"`
+}
+
+type KickPlayerRequest struct {
+	PlayerName string `json:"playerName"`
+}
+
+type TransferHostRequest struct {
+	PlayerName string `json:"playerName"`
+}
+
+type ReorderSeatsRequest struct {
+	PlayerNames []string `json:"playerNames"`
+}
+
+type SetRulesRequest struct {
+	SpecialRules []engine.SpecialRule `json:"specialRules"`
+}
+
+// roomState returns the current room settings.
+func (room *Room) roomState() RoomStateResponse {
+	playerNames := make([]string, len(room.game.Players))
+	readyPlayerNames := make([]string, 0, len(room.ready))
+	for i, player := range room.game.Players {
+		playerNames[i] = player.Name
+		if _, ok := room.ready[player.Name]; ok {
+			readyPlayerNames = append(readyPlayerNames, player.Name)
+		}
+	}
+	return RoomStateResponse{
+		Host: room.host,
+		Locked: room.locked,
+		PlayerNames: playerNames,
+		ReadyPlayerNames: readyPlayerNames,
+		SpecialRules: room.game.SortedSpecialRules(),
+	}
+}
+
+// updateHost elects a new host when there is no host or the host has left.
+// A seated and connected player is preferred, in seat order.
+func (room *Room) updateHost() {
+	isConnected := func(playerName string) bool {
+		client, ok := room.clients[playerName]
+		return ok && !client.spectator
+	}
+	isSeated := func(playerName string) bool {
+		return slices.ContainsFunc(room.game.Players, func(player *engine.Player) bool {
+			return player.Name == playerName
+		})
+	}
+	if room.host != "" && isSeated(room.host) && isConnected(room.host) {
+		return
+	}
+	newHost := ""
+	for _, player := range room.game.Players {
+		if isConnected(player.Name) {
+			newHost = player.Name
+			break
+		}
+	}
+	if newHost == "" && room.host != "" && isSeated(room.host) {
+		// nobody else is connected. keep the host until someone comes
+		return
+	}
+	if newHost == "" && len(room.game.Players) > 0 {
+		newHost = room.game.Players[0].Name
+	}
+	if newHost == room.host {
+		return
+	}
+	room.host = newHost
+	room.broadcast("ROOM_STATE", room.roomState())
+}
+
+func (room *Room) requireHost(playerName string) error {
+	if playerName != room.host {
+		return newRequestError(NotHost, textNotHost)
+	}
+	return nil
+}
+
+func (room *Room) requireNotPlaying() error {
+	if room.game.InProgress() {
+		return newRequestError(GameInProgress, textGameInProgress)
+	}
+	return nil
+}
+
+// removeSeatedPlayer removes playerName from the game and notifies everyone.
+// In the middle of a game, the seat may be kept by the leave policy of the room.
+// The player is disconnected then, since the bot or the held seat takes over the turns.
+func (room *Room) removeSeatedPlayer(playerName string) error {
+	if room.keepSeat(playerName) {
+		if client, ok := room.clients[playerName]; ok {
+			room.removeClient(client)
+		}
+		return nil
+	}
+	return room.dropSeat(playerName)
+}
+
+// dropSeat removes playerName from the game and notifies everyone.
+// The player forfeits if the game is in progress.
+func (room *Room) dropSeat(playerName string) error {
+	inProgress := room.game.InProgress()
+	if err := room.game.RemovePlayer(playerName); err != nil {
+		return newRequestError(PlayerNotFound, textPlayerNotFound)
+	}
+	if inProgress {
+		// the game before the leave has the player again
+		room.clearUndo()
+	}
+	room.withdrawVotes(playerName)
+	delete(room.ready, playerName)
+	room.broadcast("REMOVE_PLAYER", RemovePlayerDataResponse{
+		PlayerName: playerName,
+	})
+	return nil
+}
+
+func handleKickPlayer(room *Room, playerName string, data json.RawMessage) error {
+	var request KickPlayerRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return newRequestError(InvalidMessage, textInvalidRequest)
+	}
+	if err := room.requireHost(playerName); err != nil {
+		return err
+	}
+	if err := room.requireNotPlaying(); err != nil {
+		return err
+	}
+	if request.PlayerName == playerName {
+		return newRequestError(InvalidMessage, textCannotKickYourself)
+	}
+	// a spectator has no seat to remove
+	if client, ok := room.clients[request.PlayerName]; !ok || !client.spectator {
+		if err := room.removeSeatedPlayer(request.PlayerName); err != nil {
+			return err
+		}
+	}
+	room.kicked[request.PlayerName] = struct{}{}
+	if client, ok := room.clients[request.PlayerName]; ok {
+		room.sendMessage(client, encodeResponse("KICKED", MessageResponse{Message: newText(textKickedByHost).Message(client.language)}))
+		room.removeClient(client)
+	}
+	room.broadcast("ROOM_STATE", room.roomState())
+	return nil
+}
+
+func handleTransferHost(room *Room, playerName string, data json.RawMessage) error {
+	var request TransferHostRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return newRequestError(InvalidMessage, textInvalidRequest)
+	}
+	if err := room.requireHost(playerName); err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(room.game.Players, func(player *engine.Player) bool {
+		return player.Name == request.PlayerName
+	}) {
+		return newRequestError(PlayerNotFound, textPlayerNotFound)
+	}
+	room.host = request.PlayerName
+	room.broadcast("ROOM_STATE", room.roomState())
+	return nil
+}
+
+func handleLockRoom(room *Room, playerName string, locked bool) error {
+	if err := room.requireHost(playerName); err != nil {
+		return err
+	}
+	room.locked = locked
+	room.broadcast("ROOM_STATE", room.roomState())
+	return nil
+}
+
+func handleReorderSeats(room *Room, playerName string, data json.RawMessage) error {
+	var request ReorderSeatsRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return newRequestError(InvalidMessage, textInvalidRequest)
+	}
+	if err := room.requireHost(playerName); err != nil {
+		return err
+	}
+	if err := room.requireNotPlaying(); err != nil {
+		return err
+	}
+	game := room.game
+	if len(request.PlayerNames) != len(game.Players) {
+		return newRequestError(InvalidMessage, textAllSeatsRequired)
+	}
+	players := make([]*engine.Player, 0, len(game.Players))
+	for _, name := range request.PlayerNames {
+		index := slices.IndexFunc(game.Players, func(player *engine.Player) bool {
+			return player.Name == name
+		})
+		if index < 0 || slices.Contains(players, game.Players[index]) {
+			return newRequestError(InvalidMessage, textInvalidSeat, name)
+		}
+		players = append(players, game.Players[index])
+	}
+	game.Players = players
+	game.FixedSeats = true
+	room.broadcast("ROOM_STATE", room.roomState())
+	return nil
+}
+
+func handleSetRules(room *Room, playerName string, data json.RawMessage) error {
+	var request SetRulesRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return newRequestError(InvalidMessage, textInvalidRequest)
+	}
+	if err := room.requireHost(playerName); err != nil {
+		return err
+	}
+	if err := room.requireNotPlaying(); err != nil {
+		return err
+	}
+	specialRules := make(map[engine.SpecialRule]struct{})
+	for _, rule := range request.SpecialRules {
+		if _, ok := engine.StandardRule[rule]; !ok {
+			return newRequestError(InvalidMessage, textUnknownRule, rule)
+		}
+		specialRules[rule] = struct{}{}
+	}
+	room.game.SpecialRules = specialRules
+	room.broadcast("ROOM_STATE", room.roomState())
+	return nil
+}
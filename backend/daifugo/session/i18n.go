@@ -0,0 +1,220 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-playground/daifugo/engine"
+)
+
+// DefaultLanguage is used for clients which do not specify their language.
+const DefaultLanguage = engine.Japanese
+
+// SupportedLanguages are the languages of the catalog.
+var SupportedLanguages = []engine.Language{engine.Japanese, engine.English}
+
+// textKey identifies a user-facing text in the catalog.
+type textKey string
+const (
+	textInvalidRequest textKey = "invalidRequest"
+	textUnknownRequest textKey = "unknownRequest"
+	textPlayerNotFound textKey = "playerNotFound"
+	textSpectatorCannotPlay textKey = "spectatorCannotPlay"
+	textCannotSubmitCards textKey = "cannotSubmitCards"
+	textCannotStartGame textKey = "cannotStartGame"
+	textNotAllReady textKey = "notAllReady"
+	textNotHost textKey = "notHost"
+	textGameInProgress textKey = "gameInProgress"
+	textCannotKickYourself textKey = "cannotKickYourself"
+	textKickedByHost textKey = "kickedByHost"
+	textAllSeatsRequired textKey = "allSeatsRequired"
+	textInvalidSeat textKey = "invalidSeat"
+	textUnknownRule textKey = "unknownRule"
+	textTextOrStamp textKey = "textOrStamp"
+	textUnknownStamp textKey = "unknownStamp"
+	textMessageTooLong textKey = "messageTooLong"
+	textRateLimited textKey = "rateLimited"
+	textAccessDenied textKey = "accessDenied"
+	textRoomClosed textKey = "roomClosed"
+	textKicked textKey = "kicked"
+	textNameAlreadyUsed textKey = "nameAlreadyUsed"
+	textRoomLocked textKey = "roomLocked"
+	textCannotJoinDuringGame textKey = "cannotJoinDuringGame"
+	textRoomFull textKey = "roomFull"
+	textNothingToUndo textKey = "nothingToUndo"
+	textUndoPending textKey = "undoPending"
+	textUndoDisabled textKey = "undoDisabled"
+	textNoUndoRequest textKey = "noUndoRequest"
+	textCannotAnswerOwnUndo textKey = "cannotAnswerOwnUndo"
+	textPlayerAway textKey = "playerAway"
+	textSeatHeld textKey = "seatHeld"
+	textInvalidParameter textKey = "invalidParameter"
+	textDecksOutOfRange textKey = "decksOutOfRange"
+	textMaxPlayersOutOfRange textKey = "maxPlayersOutOfRange"
+	textPartnerPassCardsOutOfRange textKey = "partnerPassCardsOutOfRange"
+	textUnknownLeavePolicy textKey = "unknownLeavePolicy"
+	textRoomNotFound textKey = "roomNotFound"
+	textRoomAlreadyExists textKey = "roomAlreadyExists"
+	textNotRoomOwner textKey = "notRoomOwner"
+	// textPlain shows its argument as it is, such as an engine.RuleError
+	// which has its own translations.
+	textPlain textKey = "plain"
+)
+
+// catalog has the format of each text in each language.
+// The arguments are formatted by fmt.Sprintf after they are localized (see Text).
+var catalog = map[textKey]map[engine.Language]string{
+	textInvalidRequest: {engine.Japanese: "リクエストの形式が正しくありません", engine.English: "The request is malformed"},
+	textUnknownRequest: {engine.Japanese: "不明なリクエストです: %s", engine.English: "Unknown request: %s"},
+	textPlayerNotFound: {engine.Japanese: "プレイヤーが見つかりません", engine.English: "The player is not found"},
+	textSpectatorCannotPlay: {engine.Japanese: "観戦者は操作できません", engine.English: "Spectators cannot play"},
+	textCannotSubmitCards: {engine.Japanese: "そのカードは出せません", engine.English: "You cannot submit the cards"},
+	textCannotStartGame: {engine.Japanese: "ゲームを開始できません: %s", engine.English: "Cannot start the game: %s"},
+	textNotAllReady: {engine.Japanese: "準備ができていないプレイヤーがいます: %s", engine.English: "Some players are not ready: %s"},
+	textNotHost: {engine.Japanese: "ホストのみ操作できます", engine.English: "Only the host can do it"},
+	textGameInProgress: {engine.Japanese: "ゲーム中は変更できません", engine.English: "It cannot be changed during a game"},
+	textCannotKickYourself: {engine.Japanese: "自分自身はキックできません", engine.English: "You cannot kick yourself"},
+	textKickedByHost: {engine.Japanese: "ホストにキックされました", engine.English: "You were kicked by the host"},
+	textAllSeatsRequired: {engine.Japanese: "全員の席を指定してください", engine.English: "Specify the seats of all players"},
+	textInvalidSeat: {engine.Japanese: "席の指定が正しくありません: %s", engine.English: "Invalid seat: %s"},
+	textUnknownRule: {engine.Japanese: "不明なルールです: %s", engine.English: "Unknown rule: %s"},
+	textTextOrStamp: {engine.Japanese: "テキストかスタンプのどちらかを指定してください", engine.English: "Specify either a text or a stamp"},
+	textUnknownStamp: {engine.Japanese: "不明なスタンプです: %s", engine.English: "Unknown stamp: %s"},
+	textMessageTooLong: {engine.Japanese: "メッセージが長すぎます", engine.English: "The message is too long"},
+	textRateLimited: {engine.Japanese: "メッセージの送信が多すぎます。しばらく待ってください", engine.English: "Too many messages. Please wait a moment"},
+	textAccessDenied: {engine.Japanese: "招待コードまたは合言葉が違います", engine.English: "The invite code or the passphrase is wrong"},
+	textRoomClosed: {engine.Japanese: "部屋は閉じられました", engine.English: "The room is closed"},
+	textKicked: {engine.Japanese: "この部屋からキックされています", engine.English: "You have been kicked from this room"},
+	textNameAlreadyUsed: {engine.Japanese: "その名前は既に使われています", engine.English: "The name is already used"},
+	textRoomLocked: {engine.Japanese: "部屋がロックされています", engine.English: "The room is locked"},
+	textCannotJoinDuringGame: {engine.Japanese: "ゲームが進行中のため入室できません", engine.English: "You cannot join during a game"},
+	textRoomFull: {engine.Japanese: "部屋が満員です", engine.English: "The room is full"},
+	textNothingToUndo: {engine.Japanese: "取り消せる操作がありません", engine.English: "There is nothing to undo"},
+	textUndoPending: {engine.Japanese: "待ったへの回答を待っています", engine.English: "Waiting for the answers to the undo request"},
+	textUndoDisabled: {engine.Japanese: "この部屋では待ったはできません", engine.English: "Undo is disabled in this room"},
+	textNoUndoRequest: {engine.Japanese: "待ったは要求されていません", engine.English: "No undo is requested"},
+	textCannotAnswerOwnUndo: {engine.Japanese: "自分の待ったには回答できません", engine.English: "You cannot answer your own undo request"},
+	textPlayerAway: {engine.Japanese: "手番のプレイヤーが切断しています", engine.English: "The player on the turn is disconnected"},
+	textSeatHeld: {engine.Japanese: "手番のプレイヤーが戻るまで席が確保されています", engine.English: "The seat on the turn is held until the player comes back"},
+	textInvalidParameter: {engine.Japanese: "%sの値が正しくありません: %s", engine.English: "Invalid %s: %s"},
+	textDecksOutOfRange: {engine.Japanese: "デッキ数は1から%dの間で指定してください", engine.English: "Decks must be between 1 and %d"},
+	textMaxPlayersOutOfRange: {engine.Japanese: "最大人数は%dから%dの間で指定してください", engine.English: "MaxPlayers must be between %d and %d"},
+	textPartnerPassCardsOutOfRange: {engine.Japanese: "パートナーに渡せるカードはチーム戦で0から%d枚です", engine.English: "PartnerPassCards must be between 0 and %d in a team game"},
+	textUnknownLeavePolicy: {engine.Japanese: "退出時の扱いは%vのいずれかを指定してください", engine.English: "LeavePolicy must be one of %v"},
+	textRoomNotFound: {engine.Japanese: "部屋が見つかりません: %s", engine.English: "Room not found: %s"},
+	textRoomAlreadyExists: {engine.Japanese: "部屋は既に存在します: %s", engine.English: "Room already exists: %s"},
+	textNotRoomOwner: {engine.Japanese: "部屋の作成者のみ削除できます", engine.English: "Only the owner can delete the room"},
+	textPlain: {engine.Japanese: "%s", engine.English: "%s"},
+}
+
+// roleNames are the display names of the roles.
+var roleNames = map[engine.Language]map[engine.PlayerRole]string{
+	engine.Japanese: {
+		engine.Daifugo: "大富豪",
+		engine.Fugo: "富豪",
+		engine.Heimin: "平民",
+		engine.Hinmin: "貧民",
+		engine.Daihinmin: "大貧民",
+	},
+	engine.English: {
+		engine.Daifugo: "Grand Millionaire",
+		engine.Fugo: "Millionaire",
+		engine.Heimin: "Commoner",
+		engine.Hinmin: "Poor",
+		engine.Daihinmin: "Extreme Poor",
+	},
+}
+
+// localizable is a value which has its own translations, such as engine.RuleError.
+type localizable interface {
+	Message(language engine.Language) string
+}
+
+// Text is a user-facing text which is translated into the language of each client
+// when it is sent.
+type Text struct {
+	key textKey
+	args []any
+}
+
+func newText(key textKey, args ...any) Text {
+	return Text{key: key, args: args}
+}
+
+// Message returns the text in language, or in DefaultLanguage if it is not translated.
+func (text Text) Message(language engine.Language) string {
+	language = normalizeLanguage(language)
+	format := catalog[text.key][language]
+	args := make([]any, len(text.args))
+	for i, arg := range text.args {
+		if value, ok := arg.(localizable); ok {
+			arg = value.Message(language)
+		}
+		args[i] = arg
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// RoleNames returns the display names of the roles in language.
+func RoleNames(language engine.Language) map[engine.PlayerRole]string {
+	return roleNames[normalizeLanguage(language)]
+}
+
+// LocaleResponse tells a client its language and the display names in it.
+type LocaleResponse struct {
+	Language engine.Language `json:"language"`
+	RoleNames map[engine.PlayerRole]string `json:"roleNames"`
+}
+
+func localeFor(client *Client) LocaleResponse {
+	language := normalizeLanguage(client.language)
+	return LocaleResponse{Language: language, RoleNames: RoleNames(language)}
+}
+
+// ParseLanguage selects the language of a client.
+// lang is an explicit choice such as the query parameter and takes precedence.
+// Otherwise the most preferred supported language of acceptLanguage,
+// the value of the Accept-Language header, is used.
+func ParseLanguage(lang string, acceptLanguage string) engine.Language {
+	if language, ok := supportedLanguage(lang); ok {
+		return language
+	}
+	selected := DefaultLanguage
+	bestQuality := 0.0
+	for _, entry := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if language, ok := supportedLanguage(tag); ok && quality > bestQuality {
+			selected = language
+			bestQuality = quality
+		}
+	}
+	return selected
+}
+
+// normalizeLanguage returns the supported language of language, or DefaultLanguage.
+func normalizeLanguage(language engine.Language) engine.Language {
+	if supported, ok := supportedLanguage(string(language)); ok {
+		return supported
+	}
+	return DefaultLanguage
+}
+
+// supportedLanguage returns the supported language of a language tag such as "en-US".
+func supportedLanguage(tag string) (engine.Language, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	for _, language := range SupportedLanguages {
+		if primary == string(language) {
+			return language, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"testing"
+
+	"go-playground/daifugo/engine"
+)
+
+func Test_ParseLanguage(t *testing.T) {
+	for _, tt := range []struct {
+		lang, acceptLanguage string
+		want engine.Language
+	}{
+		{"", "", engine.Japanese},
+		{"en", "ja", engine.English},
+		{"fr", "en-US,en;q=0.9", engine.English},
+		{"", "fr-FR, en;q=0.5, ja;q=0.8", engine.Japanese},
+		{"", "fr, de", engine.Japanese},
+		{"", "en;q=bad, EN-GB;q=0.3", engine.English},
+	} {
+		if got := ParseLanguage(tt.lang, tt.acceptLanguage); got != tt.want {
+			t.Errorf("ParseLanguage(%q, %q) = %s, want %s", tt.lang, tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func Test_catalog(t *testing.T) {
+	for key, formats := range catalog {
+		for _, language := range SupportedLanguages {
+			if formats[language] == "" {
+				t.Errorf("%s is not translated into %s", key, language)
+			}
+		}
+	}
+	for _, language := range SupportedLanguages {
+		if len(RoleNames(language)) != len(roleNames[DefaultLanguage]) {
+			t.Errorf("role names are not translated into %s", language)
+		}
+	}
+
+	text := newText(textCannotStartGame, engine.ErrNotEnoughPlayers)
+	if got := text.Message(engine.English); got != "Cannot start the game: " + engine.ErrNotEnoughPlayers.Message(engine.English) {
+		t.Errorf("rule error should be translated: %s", got)
+	}
+	if got := text.Message("fr"); got != text.Message(DefaultLanguage) {
+		t.Errorf("unknown language should fall back to the default: %s", got)
+	}
+}
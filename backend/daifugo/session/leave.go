@@ -0,0 +1,136 @@
+package session
+
+import (
+	"time"
+
+	"go-playground/daifugo/engine"
+)
+
+// LeavePolicy decides what happens to the seat of a player who leaves in the middle of a game.
+// The seat is removed as soon as the game ends in any case.
+type LeavePolicy string
+const (
+	// LeaveForfeit removes the player at once. The hand goes to the trash and the player is ranked last.
+	LeaveForfeit LeavePolicy = "FORFEIT"
+	// LeaveBot lets a bot play in place of the player until the player comes back.
+	LeaveBot LeavePolicy = "BOT"
+	// LeaveHoldSeat keeps the seat for seatHoldTimeout, and the game waits for the player
+	// on the turn. The player forfeits if not back by then.
+	LeaveHoldSeat LeavePolicy = "HOLD_SEAT"
+)
+
+var LeavePolicies = []LeavePolicy{LeaveForfeit, LeaveBot, LeaveHoldSeat}
+
+const (
+	seatHoldTimeout = 2 * time.Minute
+	// botDelay is the time a bot takes for an action, so that the others can follow it.
+	botDelay = time.Second
+	botStrategy = engine.WeakestStrategy
+)
+
+// PlayerLeftResponse is broadcast when a player leaves in the middle of a game and keeps the seat.
+type PlayerLeftResponse struct {
+	PlayerName string `json:"playerName"`
+	Policy LeavePolicy `json:"policy"`
+}
+
+// keepSeat reports whether the seat of playerName is kept by the leave policy of the room,
+// and starts a bot or holds the seat then.
+func (room *Room) keepSeat(playerName string) bool {
+	player := room.game.Player(playerName)
+	// a player who has finished has nothing to play
+	if room.leavePolicy == LeaveForfeit || !room.game.InProgress() || player == nil || len(player.Cards) == 0 {
+		return false
+	}
+	if _, ok := room.bots[playerName]; ok {
+		return true
+	}
+	if _, ok := room.heldSeats[playerName]; ok {
+		return true
+	}
+	switch room.leavePolicy {
+	case LeaveBot:
+		room.bots[playerName] = struct{}{}
+		// an action of the bot cannot be undone by the player
+		room.clearUndo()
+	case LeaveHoldSeat:
+		room.heldSeats[playerName] = room.after(seatHoldTimeout, func(room *Room) {
+			if _, ok := room.heldSeats[playerName]; ok {
+				room.returnToSeat(playerName)
+				room.dropSeat(playerName)
+			}
+		})
+	}
+	room.withdrawVotes(playerName)
+	delete(room.ready, playerName)
+	room.broadcast("PLAYER_LEFT", PlayerLeftResponse{PlayerName: playerName, Policy: room.leavePolicy})
+	return true
+}
+
+// returnToSeat gives the seat kept by a bot or held back to playerName.
+func (room *Room) returnToSeat(playerName string) {
+	delete(room.bots, playerName)
+	if timer, ok := room.heldSeats[playerName]; ok {
+		timer.Stop()
+		delete(room.heldSeats, playerName)
+	}
+}
+
+// actForAbsentPlayers is called after every command. A bot plays the turn of a player who has left,
+// and the game waits for a player whose seat is held. The seats are removed when the game ends.
+func (room *Room) actForAbsentPlayers() {
+	if !room.game.InProgress() {
+		for playerName := range room.bots {
+			room.returnToSeat(playerName)
+			room.dropSeat(playerName)
+		}
+		for playerName := range room.heldSeats {
+			room.returnToSeat(playerName)
+			room.dropSeat(playerName)
+		}
+		return
+	}
+	if room.game.GameState == engine.PassingToPartner {
+		for playerName := range room.bots {
+			// a bot passes nothing to the partner
+			room.game.PassToPartner(playerName, nil)
+		}
+	}
+	if room.game.GameState == engine.PlayingCards {
+		current := room.game.CurrentPlayer()
+		if _, held := room.heldSeats[current.Name]; held {
+			room.pause(PausedSeatHeld, current.Name)
+		} else if _, ok := room.bots[current.Name]; ok && room.botTimer == nil {
+			room.botTimer = room.after(botDelay, func(room *Room) {
+				room.botTimer = nil
+				room.playBot()
+			})
+		}
+	}
+}
+
+// playBot plays the turn of the current player if it is played by a bot.
+func (room *Room) playBot() {
+	game := room.game
+	if game.GameState != engine.PlayingCards || room.undo.request != nil {
+		return
+	}
+	playerName := game.CurrentPlayer().Name
+	if _, ok := room.bots[playerName]; !ok {
+		return
+	}
+	move := game.BotMove(botStrategy)
+	if move == nil {
+		if err := game.Pass(playerName); err != nil {
+			return
+		}
+		room.gameEvents.record(GameEvent{Type: Passed, PlayerName: playerName})
+	} else {
+		if _, err := game.Submit(playerName, move); err != nil {
+			return
+		}
+		room.gameEvents.record(GameEvent{Type: CardsPlayed, PlayerName: playerName, Cards: move})
+	}
+	// the last action is of the bot now
+	room.undo = undoState{}
+}
@@ -0,0 +1,135 @@
+package session
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go-playground/daifugo/engine"
+)
+
+// leavePolicy returns a configure of newRoom which sets policy.
+func leavePolicy(policy LeavePolicy) func(room *Room) {
+	return func(room *Room) {
+		room.leavePolicy = policy
+	}
+}
+
+func Test_leaveWithBot(t *testing.T) {
+	room, first := startTestGame(t, leavePolicy(LeaveBot))
+	room.do(func(room *Room) {
+		if err := handleRemovePlayer(room, first, json.RawMessage(`{"playerName": "` + first + `"}`)); err != nil {
+			t.Fatalf("should leave: %v", err)
+		}
+		if _, ok := room.bots[first]; !ok || room.game.Player(first) == nil {
+			t.Errorf("a bot should take the seat")
+		}
+		if _, connected := room.clients[first]; connected {
+			t.Errorf("the player who left should be disconnected")
+		}
+	})
+	inProgress := true
+	for numActions := 0; inProgress; numActions++ {
+		if numActions == 1000 {
+			t.Fatalf("game with a bot did not end")
+		}
+		room.do(func(room *Room) {
+			drainClients(room)
+			game := room.game
+			if inProgress = game.InProgress(); !inProgress {
+				return
+			}
+			current := game.CurrentPlayer().Name
+			if current == first {
+				room.playBot()
+			} else if move := game.BotMove(engine.WeakestStrategy); move != nil {
+				game.Submit(current, move)
+			} else {
+				game.Pass(current)
+			}
+			if err := game.CheckInvariants(); err != nil {
+				t.Fatalf("invariant is broken: %v", err)
+			}
+		})
+	}
+	room.do(func(room *Room) {
+		if room.game.Player(first) != nil || len(room.bots) != 0 {
+			t.Errorf("the seat of the bot should be removed after the game")
+		}
+		if len(room.game.Results) != 1 || len(room.game.Results[0].PlayersByRank) != 3 {
+			t.Errorf("the bot should be ranked: %v", room.game.Results)
+		}
+	})
+}
+
+func Test_leaveWithHeldSeat(t *testing.T) {
+	room, first := startTestGame(t, leavePolicy(LeaveHoldSeat))
+	room.do(func(room *Room) {
+		handleRemovePlayer(room, first, json.RawMessage(`{"playerName": "` + first + `"}`))
+		if _, connected := room.clients[first]; connected {
+			t.Errorf("the player who left should be disconnected")
+		}
+	})
+	room.do(func(room *Room) {
+		if _, ok := room.heldSeats[first]; !ok || room.game.GameState != engine.Paused {
+			t.Errorf("the game should wait for the held seat: %s", room.game.GameState)
+		}
+		if err := handleResume(room, room.host); err == nil || err.(*RequestError).Code != SeatHeld {
+			t.Errorf("the host cannot resume the game while the turn is held: %v", err)
+		}
+	})
+	room.do(func(room *Room) {
+		if room.game.GameState != engine.Paused || room.game.PausedState != engine.PlayingCards {
+			t.Errorf("the game should stay paused on the held turn: %s", room.game.GameState)
+		}
+	})
+	if err := room.Join(NewClient(first, false, DefaultLanguage), false); err != nil {
+		t.Fatalf("the player should come back: %v", err)
+	}
+	room.do(func(room *Room) {
+		if len(room.heldSeats) != 0 || room.game.Player(first) == nil {
+			t.Errorf("the seat should be given back")
+		}
+		handleResume(room, room.host)
+	})
+	room.do(func(room *Room) {
+		if room.game.GameState != engine.PlayingCards || room.game.CurrentPlayer().Name != first {
+			t.Errorf("the player should play the turn: %s", room.game.GameState)
+		}
+	})
+}
+
+func Test_leaveWithForfeit(t *testing.T) {
+	room, first := startTestGame(t, leavePolicy(LeaveForfeit))
+	room.do(func(room *Room) {
+		handleRemovePlayer(room, first, json.RawMessage(`{"playerName": "` + first + `"}`))
+		if room.game.Player(first) != nil || room.game.CurrentPlayer() == nil || room.game.CurrentPlayer().Name == first {
+			t.Errorf("the player should forfeit and the next player should have the turn")
+		}
+	})
+}
+
+func Test_reconnectKeepsSeat(t *testing.T) {
+	// the default leave policy is for REMOVE_PLAYER, and a disconnect keeps the seat
+	room, first := startTestGame(t, nil)
+	var client *Client
+	room.do(func(room *Room) {
+		client = room.clients[first]
+	})
+	room.Leave(client)
+	room.do(func(room *Room) {
+		if room.game.Player(first) == nil || room.game.GameState != engine.Paused {
+			t.Errorf("the seat should be kept and the game paused: %s", room.game.GameState)
+		}
+	})
+	if err := room.Join(NewClient(first, false, DefaultLanguage), false); err != nil {
+		t.Fatalf("the player should return to the seat: %v", err)
+	}
+	room.do(func(room *Room) {
+		if room.game.Player(first) == nil || room.game.GameState != engine.Paused {
+			t.Errorf("the player should be seated in the paused game: %s", room.game.GameState)
+		}
+		if err := handleResume(room, room.host); err != nil {
+			t.Errorf("the game should be resumed after the reconnect: %v", err)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package session
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+
+	"go-playground/daifugo/engine"
+)
+
+const (
+	// Number of lobby events buffered per subscriber.
+	// A subscriber whose buffer is full is dropped, and is expected to reconnect
+	// to get a fresh snapshot.
+	lobbyBufferSize = 32
+)
+
+// RoomSummary is an entry of the room list.
+type RoomSummary struct {
+	RoomInfo
+	NumPlayers int `json:"numPlayers"`
+	NumSpectators int `json:"numSpectators"`
+	GameState engine.GameState `json:"gameState"`
+	SpecialRules []engine.SpecialRule `json:"specialRules"`
+	Joinable bool `json:"joinable"`
+}
+
+type LobbyEventType string
+const (
+	RoomUpdated LobbyEventType = "ROOM_UPDATED"
+	RoomRemoved LobbyEventType = "ROOM_REMOVED"
+)
+
+type LobbyEvent struct {
+	Type LobbyEventType `json:"type"`
+	Room RoomSummary `json:"room"`
+}
+
+// summary returns the summary of the room.
+func (room *Room) summary() RoomSummary {
+	numSpectators := 0
+	for _, client := range room.clients {
+		if client.spectator {
+			numSpectators++
+		}
+	}
+	return RoomSummary{
+		RoomInfo: room.info(),
+		NumPlayers: len(room.game.Players),
+		NumSpectators: numSpectators,
+		GameState: room.game.GameState,
+		SpecialRules: room.game.SortedSpecialRules(),
+		Joinable: room.isJoinable(),
+	}
+}
+
+// publishSummary notifies the lobby if the summary has changed since the last call.
+func (room *Room) publishSummary() {
+	summary := room.summary()
+	if room.lastSummary != nil && reflect.DeepEqual(*room.lastSummary, summary) {
+		return
+	}
+	room.lastSummary = &summary
+	if !room.private {
+		lobby.publish(LobbyEvent{Type: RoomUpdated, Room: summary})
+	}
+}
+
+// lobbyHub delivers room list changes to the lobby subscribers.
+type lobbyHub struct {
+	mu sync.Mutex
+	subscribers map[chan LobbyEvent]struct{}
+	// summaries are the last published summaries of the public rooms by name.
+	// A new subscriber gets them as the snapshot under the same lock as the subscription,
+	// so that no event older than the snapshot is delivered after it.
+	summaries map[string]RoomSummary
+}
+
+var lobby = &lobbyHub{
+	subscribers: make(map[chan LobbyEvent]struct{}),
+	summaries: make(map[string]RoomSummary),
+}
+
+func (hub *lobbyHub) subscribe() ([]RoomSummary, chan LobbyEvent) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	events := make(chan LobbyEvent, lobbyBufferSize)
+	hub.subscribers[events] = struct{}{}
+	snapshot := make([]RoomSummary, 0, len(hub.summaries))
+	for _, summary := range hub.summaries {
+		snapshot = append(snapshot, summary)
+	}
+	sortByName(snapshot)
+	return snapshot, events
+}
+
+func (hub *lobbyHub) unsubscribe(events chan LobbyEvent) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if _, ok := hub.subscribers[events]; ok {
+		delete(hub.subscribers, events)
+		close(events)
+	}
+}
+
+func (hub *lobbyHub) publish(event LobbyEvent) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	switch event.Type {
+	case RoomUpdated:
+		hub.summaries[event.Room.Name] = event.Room
+	case RoomRemoved:
+		delete(hub.summaries, event.Room.Name)
+	}
+	for events := range hub.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(hub.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// SubscribeLobby returns the summaries of the public rooms sorted by name,
+// and a channel of the room list changes after them.
+// The channel is closed when the subscriber is too slow, and it should subscribe again then.
+func SubscribeLobby() ([]RoomSummary, chan LobbyEvent) {
+	return lobby.subscribe()
+}
+
+func UnsubscribeLobby(events chan LobbyEvent) {
+	lobby.unsubscribe(events)
+}
+
+// ListRoomSummaries returns the summaries of rooms sorted by name.
+// Private rooms are included only when inviteCode matches.
+func ListRoomSummaries(inviteCode string) []RoomSummary {
+	mu.Lock()
+	roomList := make([]*Room, 0, len(rooms))
+	for _, room := range rooms {
+		roomList = append(roomList, room)
+	}
+	mu.Unlock()
+
+	summaries := make([]RoomSummary, 0, len(roomList))
+	for _, room := range roomList {
+		var summary RoomSummary
+		visible := false
+		room.do(func(room *Room) {
+			visible = !room.private || room.hasInviteCode(inviteCode)
+			summary = room.summary()
+		})
+		if visible {
+			summaries = append(summaries, summary)
+		}
+	}
+	sortByName(summaries)
+	return summaries
+}
+
+func sortByName(summaries []RoomSummary) {
+	slices.SortFunc(summaries, func(a, b RoomSummary) int {
+		if a.Name < b.Name {
+			return -1
+		}
+		if a.Name > b.Name {
+			return 1
+		}
+		return 0
+	})
+}
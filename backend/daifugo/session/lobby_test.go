@@ -0,0 +1,28 @@
+package session
+
+import (
+	"testing"
+)
+
+func Test_lobbySnapshot(t *testing.T) {
+	hub := &lobbyHub{
+		subscribers: make(map[chan LobbyEvent]struct{}),
+		summaries: make(map[string]RoomSummary),
+	}
+	for _, roomName := range []string{"b", "a", "c"} {
+		hub.publish(LobbyEvent{Type: RoomUpdated, Room: RoomSummary{RoomInfo: RoomInfo{Name: roomName}}})
+	}
+	hub.publish(LobbyEvent{Type: RoomRemoved, Room: RoomSummary{RoomInfo: RoomInfo{Name: "c"}}})
+	snapshot, events := hub.subscribe()
+	if len(snapshot) != 2 || snapshot[0].Name != "a" || snapshot[1].Name != "b" {
+		t.Errorf("snapshot should have the rooms sorted by name: %+v", snapshot)
+	}
+	if len(events) != 0 {
+		t.Errorf("no event older than the snapshot should be delivered: %d", len(events))
+	}
+	hub.publish(LobbyEvent{Type: RoomUpdated, Room: RoomSummary{RoomInfo: RoomInfo{Name: "d"}}})
+	if event := <-events; event.Room.Name != "d" {
+		t.Errorf("events after the snapshot should be delivered: %+v", event)
+	}
+	hub.unsubscribe(events)
+}
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"go-playground/daifugo/engine"
+)
+
+type PauseReason string
+const (
+	// PausedByHost is also used when the host resumes the game.
+	PausedByHost PauseReason = "HOST"
+	PausedByVote PauseReason = "VOTE"
+	// PausedOnDisconnect is used when the current player is disconnected.
+	PausedOnDisconnect PauseReason = "DISCONNECTED"
+	// PausedSeatHeld is used on the turn of a player whose seat is held for reconnection.
+	PausedSeatHeld PauseReason = "SEAT_HELD"
+)
+
+// PauseResponse is broadcast as GAME_PAUSED and GAME_RESUMED.
+type PauseResponse struct {
+	Reason PauseReason `json:"reason"`
+	// PlayerName is the host, the last voter, or the disconnected player.
+	PlayerName string `json:"playerName"`
+}
+
+// handlePause pauses the game at once if playerName is the host, or votes for it otherwise.
+func handlePause(room *Room, playerName string) error {
+	if room.game.GameState == engine.Paused {
+		return engineError(engine.ErrGamePaused)
+	}
+	if !room.game.InProgress() {
+		return engineError(engine.ErrGameNotInProgress)
+	}
+	reason := PausedByHost
+	if playerName != room.host {
+		passed, err := room.vote(votePause, playerName)
+		if err != nil || !passed {
+			return err
+		}
+		reason = PausedByVote
+	}
+	return room.pause(reason, playerName)
+}
+
+// handleResume resumes the game at once if playerName is the host, or votes for it otherwise.
+func handleResume(room *Room, playerName string) error {
+	if room.game.GameState != engine.Paused {
+		return engineError(engine.ErrNotPaused)
+	}
+	// the game would be paused again at once
+	if current := room.game.CurrentPlayer(); room.game.PausedState == engine.PlayingCards && current != nil {
+		if _, held := room.heldSeats[current.Name]; held {
+			return newRequestError(SeatHeld, textSeatHeld)
+		}
+		if room.isAway(current.Name) {
+			return newRequestError(PlayerAway, textPlayerAway)
+		}
+	}
+	reason := PausedByHost
+	if playerName != room.host {
+		passed, err := room.vote(voteResume, playerName)
+		if err != nil || !passed {
+			return err
+		}
+		reason = PausedByVote
+	}
+	if err := room.game.Resume(); err != nil {
+		return engineError(err)
+	}
+	room.clearPauseVotes()
+	room.broadcast("GAME_RESUMED", PauseResponse{Reason: reason, PlayerName: playerName})
+	return nil
+}
+
+// pause pauses the game and notifies everyone.
+func (room *Room) pause(reason PauseReason, playerName string) error {
+	if err := room.game.Pause(); err != nil {
+		return engineError(err)
+	}
+	room.clearPauseVotes()
+	room.broadcast("GAME_PAUSED", PauseResponse{Reason: reason, PlayerName: playerName})
+	return nil
+}
+
+// pauseIfAway pauses the game when the current player is away. It is called after every command,
+// so the game also pauses when the turn comes to a player who has disconnected on another turn.
+func (room *Room) pauseIfAway() {
+	if room.game.GameState != engine.PlayingCards {
+		return
+	}
+	if current := room.game.CurrentPlayer(); current != nil && room.isAway(current.Name) {
+		room.pause(PausedOnDisconnect, current.Name)
+	}
+}
+
+// isAway reports whether playerName is neither connected nor played by a bot.
+func (room *Room) isAway(playerName string) bool {
+	if _, connected := room.clients[playerName]; connected {
+		return false
+	}
+	_, bot := room.bots[playerName]
+	return !bot
+}
+
+// isPresent reports whether playerName is connected and plays the own seat,
+// which is neither played by a bot nor held.
+func (room *Room) isPresent(playerName string) bool {
+	_, connected := room.clients[playerName]
+	_, bot := room.bots[playerName]
+	_, held := room.heldSeats[playerName]
+	return connected && !bot && !held
+}
+
+// clearPauseVotes drops the votes which are meaningless after a pause or a resume.
+func (room *Room) clearPauseVotes() {
+	delete(room.votes, votePause)
+	delete(room.votes, voteResume)
+}
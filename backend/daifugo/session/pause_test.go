@@ -0,0 +1,69 @@
+package session
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go-playground/daifugo/engine"
+)
+
+func Test_pause(t *testing.T) {
+	room, _ := startTestGame(t, nil)
+	room.do(func(room *Room) {
+		if err := handlePause(room, "p2"); err != nil || room.game.GameState != engine.PlayingCards {
+			t.Errorf("a vote should wait for the majority: %v %s", err, room.game.GameState)
+		}
+		if err := handlePause(room, "p3"); err != nil || room.game.GameState != engine.Paused {
+			t.Errorf("the majority should pause: %v %s", err, room.game.GameState)
+		}
+		if err := handlePass(room, room.game.CurrentPlayer().Name); err == nil {
+			t.Errorf("play should be rejected while paused")
+		}
+		if err := handleResume(room, "p2"); err != nil || room.game.GameState != engine.Paused || len(room.votes[votePause]) != 0 {
+			t.Errorf("a vote to resume should wait for the majority: %v %s", err, room.game.GameState)
+		}
+		if err := handleResume(room, "p1"); err != nil || room.game.GameState != engine.PlayingCards || len(room.votes) != 0 {
+			t.Errorf("the host should resume at once: %v %s", err, room.game.GameState)
+		}
+		if err := handleResume(room, "p1"); err == nil {
+			t.Errorf("a game not paused cannot be resumed")
+		}
+	})
+}
+
+func Test_pauseOnTurnOfDisconnectedPlayer(t *testing.T) {
+	room := newTestRoom(t, nil)
+	var p3 *Client
+	room.do(func(room *Room) {
+		p3 = room.clients["p3"]
+	})
+	// p3 is disconnected before the game, and keeps the seat
+	room.Leave(p3)
+	room.do(func(room *Room) {
+		handleGameStart(room, "p1", json.RawMessage(`{"force": true}`))
+	})
+	paused := false
+	for numActions := 0; !paused && numActions < 3; numActions++ {
+		room.do(func(room *Room) {
+			game := room.game
+			if paused = game.GameState == engine.Paused; paused {
+				return
+			}
+			if game.CurrentPlayer().Name == "p3" {
+				t.Errorf("the game should pause on the turn of the disconnected player: %s", game.GameState)
+			}
+			game.Submit(game.CurrentPlayer().Name, game.BotMove(engine.WeakestStrategy))
+		})
+	}
+	room.do(func(room *Room) {
+		if room.game.GameState != engine.Paused || room.game.CurrentPlayer().Name != "p3" {
+			t.Errorf("the game should pause on the turn of the disconnected player: %s", room.game.GameState)
+		}
+		if err := handlePause(room, "p1"); err == nil {
+			t.Errorf("a paused game cannot be paused again")
+		}
+		if err := handleResume(room, "p1"); err == nil || err.(*RequestError).Code != PlayerAway {
+			t.Errorf("the game cannot be resumed while the player is away: %v", err)
+		}
+	})
+}
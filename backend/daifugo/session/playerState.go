@@ -0,0 +1,23 @@
+package session
+
+import (
+	"bytes"
+)
+
+// syncPlayerStates sends PLAYER_STATE to each player whose private state has changed
+// since the last one. The state is engine.PlayerView, so players never need
+// to patch their hands by themselves. The public state is sent by GAME_EVENT and GAME_SNAPSHOT.
+// Spectators have no private state.
+func (room *Room) syncPlayerStates() {
+	for _, client := range room.clients {
+		if client.spectator {
+			continue
+		}
+		state := encodeResponse("PLAYER_STATE", room.game.ViewFor(client.playerName))
+		if bytes.Equal(state, client.lastState) {
+			continue
+		}
+		client.lastState = state
+		room.sendMessage(client, state)
+	}
+}
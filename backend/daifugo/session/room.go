@@ -0,0 +1,485 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"go-playground/daifugo/engine"
+)
+
+type RoomStatus string
+const (
+	RoomStatusWaiting RoomStatus = "Waiting"
+	RoomStatusPlaying RoomStatus = "Playing"
+	RoomStatusClosed RoomStatus = "Closed"
+)
+
+// Room is an actor which owns its clients and game.
+// Every access to them is a command executed sequentially on the room goroutine,
+// so neither the game engine nor the clients map needs a lock.
+// The exported methods pass such commands to do. The unexported methods and the message
+// handlers access the room directly, so they must be called on the room goroutine.
+type Room struct {
+	name string
+	creator string
+	createdAt time.Time
+	maxPlayers int
+	// ownerToken authorizes the creator to delete the room.
+	ownerToken string
+	// private rooms are hidden from the room list and can be joined only
+	// with the invite code or the passphrase.
+	private bool
+	inviteCode string
+	// passphraseHash is empty when the room has no passphrase.
+	passphraseHash string
+	// lastActiveAt is updated when a client joins or leaves.
+	lastActiveAt time.Time
+	// host is the player who can start the game and change the settings.
+	// It is empty until the first player joins.
+	host string
+	// locked rooms reject new players.
+	locked bool
+	// kicked players cannot join again.
+	kicked map[string]struct{}
+	// ready holds the players who are ready for the next game.
+	ready map[string]struct{}
+	chat chatLog
+	gameEvents gameEventLog
+	// undo is the last action which can be taken back unless disableUndo is set.
+	undo undoState
+	disableUndo bool
+	// votes holds the voters of each kind of vote in progress.
+	votes map[voteKind][]string
+	// leavePolicy decides the seats of the players leaving in the middle of a game.
+	// bots are the players played by bots, and heldSeats are the players waited for.
+	leavePolicy LeavePolicy
+	bots map[string]struct{}
+	heldSeats map[string]*time.Timer
+	// botTimer is set while a bot is thinking.
+	botTimer *time.Timer
+	// lastSummary is the summary last published to the lobby.
+	lastSummary *RoomSummary
+	clients map[string]*Client
+	game *engine.Game
+	commands chan func(room *Room)
+	done chan struct{}
+	closeOnce sync.Once
+}
+
+// RoomInfo is the metadata of a room returned by the HTTP API.
+type RoomInfo struct {
+	Name string `json:"name"`
+	Creator string `json:"creator"`
+	CreatedAt time.Time `json:"createdAt"`
+	MaxPlayers int `json:"maxPlayers"`
+	// Decks is the num of decks combined in the game.
+	Decks int `json:"decks"`
+	// Teams is true for the team variant, in which partners sit opposite.
+	Teams bool `json:"teams"`
+	// DisableUndo is true if the last action cannot be undone in the room.
+	DisableUndo bool `json:"disableUndo"`
+	LeavePolicy LeavePolicy `json:"leavePolicy"`
+	Status RoomStatus `json:"status"`
+	Private bool `json:"private"`
+}
+
+var (
+	rooms = make(map[string]*Room)
+	mu    sync.Mutex
+)
+
+func newRoom(name string, creator string, maxPlayers int, options engine.Options, configure func(room *Room)) *Room {
+	now := time.Now()
+	room := &Room{
+		name: name,
+		creator: creator,
+		createdAt: now,
+		maxPlayers: maxPlayers,
+		ownerToken: generateToken(),
+		lastActiveAt: now,
+		kicked: make(map[string]struct{}),
+		ready: make(map[string]struct{}),
+		votes: make(map[voteKind][]string),
+		leavePolicy: LeaveForfeit,
+		bots: make(map[string]struct{}),
+		heldSeats: make(map[string]*time.Timer),
+		clients: make(map[string]*Client),
+		game: engine.NewGame(options),
+		commands: make(chan func(room *Room)),
+		done: make(chan struct{}),
+	}
+	if configure != nil {
+		configure(room)
+	}
+	// published before the room goroutine starts, so the lobby knows the room once it is created
+	room.publishSummary()
+	go room.run()
+	return room
+}
+
+// makePrivate hides the room and generates its invite code.
+// It must be called before the room is published.
+func (room *Room) makePrivate(passphrase string) {
+	room.private = true
+	room.inviteCode = generateInviteCode()
+	if passphrase != "" {
+		room.passphraseHash = hashPassphrase(passphrase)
+	}
+}
+
+// canAccess reports whether the invite code or the passphrase allows to enter the room.
+// Public rooms can be accessed without them.
+func (room *Room) canAccess(inviteCode string, passphrase string) bool {
+	if !room.private {
+		return true
+	}
+	if room.hasInviteCode(inviteCode) {
+		return true
+	}
+	return room.passphraseHash != "" && passphrase != "" &&
+		subtle.ConstantTimeCompare([]byte(room.passphraseHash), []byte(hashPassphrase(passphrase))) == 1
+}
+
+// hasInviteCode reports whether inviteCode is the invite code of the room.
+func (room *Room) hasInviteCode(inviteCode string) bool {
+	return room.inviteCode != "" && subtle.ConstantTimeCompare([]byte(room.inviteCode), []byte(inviteCode)) == 1
+}
+
+func hashPassphrase(passphrase string) string {
+	hash := sha256.Sum256([]byte(passphrase))
+	return hex.EncodeToString(hash[:])
+}
+
+const inviteCodeLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// generateInviteCode returns a short code which is easy to read aloud.
+func generateInviteCode() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	for i, b := range bytes {
+		bytes[i] = inviteCodeLetters[int(b)%len(inviteCodeLetters)]
+	}
+	return string(bytes)
+}
+
+func generateToken() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// status returns the current status of the room.
+func (room *Room) status() RoomStatus {
+	select {
+	case <-room.done:
+		return RoomStatusClosed
+	default:
+	}
+	if room.game.InProgress() {
+		return RoomStatusPlaying
+	}
+	return RoomStatusWaiting
+}
+
+// info returns the metadata of the room.
+func (room *Room) info() RoomInfo {
+	return RoomInfo{
+		Name: room.name,
+		Creator: room.creator,
+		CreatedAt: room.createdAt,
+		MaxPlayers: room.maxPlayers,
+		Decks: room.game.Decks,
+		Teams: room.game.Teams,
+		DisableUndo: room.disableUndo,
+		LeavePolicy: room.leavePolicy,
+		Status: room.status(),
+		Private: room.private,
+	}
+}
+
+// isJoinable reports whether a new player can join the room.
+func (room *Room) isJoinable() bool {
+	return !room.locked && !room.game.InProgress() && len(room.game.Players) < room.maxPlayers
+}
+
+// canJoin checks whether playerName can join the room.
+// A player who is already seated can always come back.
+// Spectators can join any time unless the name is used by a player.
+func (room *Room) canJoin(playerName string, spectator bool) *RequestError {
+	seated := false
+	for _, player := range room.game.Players {
+		if player.Name == playerName {
+			seated = true
+		}
+	}
+	client, connected := room.clients[playerName]
+	if _, ok := room.kicked[playerName]; ok {
+		return newRequestError(Kicked, textKicked)
+	}
+	if spectator {
+		if seated || (connected && !client.spectator) {
+			return newRequestError(NameAlreadyUsed, textNameAlreadyUsed)
+		}
+		return nil
+	}
+	if connected && client.spectator {
+		return newRequestError(NameAlreadyUsed, textNameAlreadyUsed)
+	}
+	if seated {
+		return nil
+	}
+	if room.locked {
+		return newRequestError(RoomLocked, textRoomLocked)
+	}
+	if room.game.InProgress() {
+		return newRequestError(GameInProgress, textCannotJoinDuringGame)
+	}
+	if len(room.game.Players) >= room.maxPlayers {
+		return newRequestError(RoomFull, textRoomFull)
+	}
+	return nil
+}
+
+// isIdle reports whether the room has had no clients for ttl.
+func (room *Room) isIdle(now time.Time, ttl time.Duration) bool {
+	return len(room.clients) == 0 && now.Sub(room.lastActiveAt) >= ttl
+}
+
+func (room *Room) run() {
+	for {
+		select {
+		case command := <-room.commands:
+			command(room)
+			room.actForAbsentPlayers()
+			room.pauseIfAway()
+			room.updateHost()
+			room.syncGameEvents()
+			room.syncPlayerStates()
+			room.publishSummary()
+		case <-room.done:
+			for _, client := range room.clients {
+				room.removeClient(client)
+			}
+			if !room.private {
+				lobby.publish(LobbyEvent{Type: RoomRemoved, Room: room.summary()})
+			}
+			return
+		}
+	}
+}
+
+// do executes command on the room goroutine and waits for it to finish.
+// It returns false if the room is already closed.
+// It must not be called from the room goroutine itself.
+func (room *Room) do(command func(room *Room)) bool {
+	finished := make(chan struct{})
+	select {
+	case room.commands <- func(room *Room) {
+		defer close(finished)
+		command(room)
+	}:
+	case <-room.done:
+		return false
+	}
+	<-finished
+	return true
+}
+
+// after executes command on the room goroutine once d has elapsed.
+// The returned timer can be used to cancel it.
+func (room *Room) after(d time.Duration, command func(room *Room)) *time.Timer {
+	return time.AfterFunc(d, func() {
+		room.do(command)
+	})
+}
+
+// close stops the room goroutine and disconnects all clients.
+func (room *Room) close() {
+	room.closeOnce.Do(func() {
+		close(room.done)
+	})
+}
+
+// GetOrCreateRoom retrieves a room by name or creates a new one if it doesn't exist.
+// created reports whether the room was created by this call.
+func GetOrCreateRoom(roomName string, creator string) (room *Room, created bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	room, exists := rooms[roomName]
+	if !exists {
+		room = newRoom(roomName, creator, engine.MaxPlayers, engine.Options{}, nil)
+		rooms[roomName] = room
+	}
+	return room, !exists
+}
+
+// DiscardCreatedRoom deletes room created by GetOrCreateRoom when its creator cannot join it,
+// so that a rejected request does not leave an empty room. A room which has got a client or a player
+// in the meantime is kept.
+func DiscardCreatedRoom(room *Room) {
+	empty := false
+	room.do(func(room *Room) {
+		empty = len(room.clients) == 0 && len(room.game.Players) == 0
+	})
+	if empty {
+		deleteRoom(room)
+	}
+}
+
+// RoomOptions configures CreateRoom.
+type RoomOptions struct {
+	// MaxPlayers is engine.MaxPlayersFor(Decks) if it is zero.
+	MaxPlayers int
+	// Decks is the num of decks combined. engine.DecksFor(MaxPlayers) is used if it is zero.
+	Decks int
+	// Teams enables the team variant, and PartnerPassCards lets each player pass up to
+	// that many cards to the partner at the start of a game.
+	Teams bool
+	PartnerPassCards int
+	// DisableUndo rejects REQUEST_UNDO, e.g. for ranked rooms.
+	DisableUndo bool
+	// LeavePolicy is LeaveForfeit if it is empty.
+	LeavePolicy LeavePolicy
+	// Private rooms are hidden from the room list. A room with Passphrase is always private.
+	Private bool
+	Passphrase string
+}
+
+// Validate checks the options requested by a client.
+func (options RoomOptions) Validate() *RequestError {
+	if options.Decks < 0 || options.Decks > engine.MaxDecks {
+		return newRequestError(InvalidMessage, textDecksOutOfRange, engine.MaxDecks)
+	}
+	decks := options.Decks
+	if decks == 0 {
+		decks = engine.DecksFor(options.MaxPlayers)
+	}
+	maxPlayers := engine.MaxPlayersFor(decks)
+	if options.MaxPlayers != 0 && (options.MaxPlayers < engine.MinPlayers || options.MaxPlayers > maxPlayers) {
+		return newRequestError(InvalidMessage, textMaxPlayersOutOfRange, engine.MinPlayers, maxPlayers)
+	}
+	if options.PartnerPassCards < 0 || options.PartnerPassCards > engine.MaxPartnerPassCards || (options.PartnerPassCards > 0 && !options.Teams) {
+		return newRequestError(InvalidMessage, textPartnerPassCardsOutOfRange, engine.MaxPartnerPassCards)
+	}
+	if options.LeavePolicy != "" && !slices.Contains(LeavePolicies, options.LeavePolicy) {
+		return newRequestError(InvalidMessage, textUnknownLeavePolicy, LeavePolicies)
+	}
+	return nil
+}
+
+// CreateRoom creates a new room. It returns nil if the room already exists.
+func CreateRoom(roomName string, creator string, options RoomOptions) *Room {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := rooms[roomName]; exists {
+		return nil
+	}
+	decks := options.Decks
+	if decks == 0 {
+		decks = engine.DecksFor(options.MaxPlayers)
+	}
+	maxPlayers := options.MaxPlayers
+	if maxPlayers == 0 {
+		maxPlayers = engine.MaxPlayersFor(decks)
+	}
+	room := newRoom(roomName, creator, maxPlayers, engine.Options{
+		Decks: decks,
+		Teams: options.Teams,
+		PartnerPassCards: options.PartnerPassCards,
+	}, func(room *Room) {
+		if options.Private || options.Passphrase != "" {
+			room.makePrivate(options.Passphrase)
+		}
+		room.disableUndo = options.DisableUndo
+		if options.LeavePolicy != "" {
+			room.leavePolicy = options.LeavePolicy
+		}
+	})
+	rooms[roomName] = room
+	return room
+}
+
+// deleteRoom closes the room and removes it from rooms.
+func deleteRoom(room *Room) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if rooms[room.name] == room {
+		delete(rooms, room.name)
+	}
+	room.close()
+}
+
+// CloseAllRooms closes every room and disconnects all clients.
+func CloseAllRooms() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for roomName, room := range rooms {
+		room.close()
+		delete(rooms, roomName)
+	}
+}
+
+// reapIdleRooms closes rooms which have been empty for ttl.
+func reapIdleRooms(ttl time.Duration) {
+	// a busy room must not block the others while it is asked, so mu is not held then
+	mu.Lock()
+	roomList := make([]*Room, 0, len(rooms))
+	for _, room := range rooms {
+		roomList = append(roomList, room)
+	}
+	mu.Unlock()
+
+	now := time.Now()
+	for _, room := range roomList {
+		idle := false
+		room.do(func(room *Room) {
+			idle = room.isIdle(now, ttl)
+		})
+		if idle {
+			log.Printf("closing idle room: %s", room.name)
+			deleteRoom(room)
+		}
+	}
+}
+
+// StartRoomReaper closes rooms which have been empty for ttl in the background.
+// Call the returned function to stop it.
+func StartRoomReaper(ttl time.Duration) (stop func()) {
+	interval := ttl / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				reapIdleRooms(ttl)
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+// GetRoom retrieves a room by name. It returns nil if the room doesn't exist.
+func GetRoom(roomName string) *Room {
+	mu.Lock()
+	defer mu.Unlock()
+	return rooms[roomName]
+}
@@ -0,0 +1,157 @@
+package session
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-playground/daifugo/engine"
+)
+
+// newTestRoom returns a room hosted by p1 with p1, p2 and p3 seated and connected.
+// configure is passed to newRoom, and the room is closed when the test ends.
+func newTestRoom(t testing.TB, configure func(room *Room)) *Room {
+	room := newRoom(t.Name(), "p1", engine.MaxPlayers, engine.Options{}, configure)
+	t.Cleanup(room.close)
+	room.do(func(room *Room) {
+		for _, playerName := range []string{"p1", "p2", "p3"} {
+			room.game.AddPlayer(playerName)
+			room.addClient(NewClient(playerName, false, DefaultLanguage))
+		}
+		room.updateHost()
+	})
+	return room
+}
+
+// startTestGame force starts a game in a room of newTestRoom,
+// and returns the room and the player who has the first turn.
+func startTestGame(t testing.TB, configure func(room *Room)) (*Room, string) {
+	room := newTestRoom(t, configure)
+	var err error
+	var first string
+	room.do(func(room *Room) {
+		if err = handleGameStart(room, "p1", json.RawMessage(`{"force": true}`)); err == nil {
+			first = room.game.CurrentPlayer().Name
+		}
+	})
+	if err != nil {
+		t.Fatalf("cannot start: %v", err)
+	}
+	return room, first
+}
+
+// drainClients empties the queues of the clients, which no transport reads in the tests,
+// so that they are not disconnected as too slow.
+func drainClients(room *Room) {
+	for _, client := range room.clients {
+		for len(client.send) > 0 {
+			<-client.send
+		}
+	}
+}
+
+func Test_doAfterClose(t *testing.T) {
+	room := newRoom("Test_doAfterClose", "", engine.MaxPlayers, engine.Options{}, nil)
+	if !room.do(func(room *Room) {}) {
+		t.Errorf("do should succeed while the room is running")
+	}
+	room.close()
+	if room.do(func(room *Room) {}) {
+		t.Errorf("do should fail after the room is closed")
+	}
+}
+
+func Test_canJoin(t *testing.T) {
+	room := newRoom("Test_canJoin", "p1", 2, engine.Options{}, nil)
+	defer room.close()
+	room.do(func(room *Room) {
+		room.game.AddPlayer("p1")
+		room.game.AddPlayer("p2")
+		if err := room.canJoin("p3", false); err == nil || err.Code != RoomFull {
+			t.Errorf("should be RoomFull but %v", err)
+		}
+		if err := room.canJoin("p1", false); err != nil {
+			t.Errorf("seated player should be able to come back but %v", err)
+		}
+		room.maxPlayers = 3
+		room.game.Start()
+		if err := room.canJoin("p3", false); err == nil || err.Code != GameInProgress {
+			t.Errorf("should be GameInProgress but %v", err)
+		}
+	})
+}
+
+func Test_reapIdleRooms(t *testing.T) {
+	idle, _ := GetOrCreateRoom("Test_reapIdleRooms_idle", "")
+	active, _ := GetOrCreateRoom("Test_reapIdleRooms_active", "")
+	idle.do(func(room *Room) {
+		room.lastActiveAt = time.Now().Add(-time.Hour)
+	})
+	active.Join(NewClient("p1", false, DefaultLanguage), false)
+	active.do(func(room *Room) {
+		room.lastActiveAt = time.Now().Add(-time.Hour)
+	})
+
+	reapIdleRooms(time.Minute)
+
+	if GetRoom("Test_reapIdleRooms_idle") != nil {
+		t.Errorf("idle room should be reaped")
+	}
+	if idle.do(func(room *Room) {}) {
+		t.Errorf("idle room should be closed")
+	}
+	if GetRoom("Test_reapIdleRooms_active") == nil {
+		t.Errorf("room with a client should not be reaped")
+	}
+	CloseAllRooms()
+}
+
+func Test_discardCreatedRoom(t *testing.T) {
+	empty, _ := GetOrCreateRoom("Test_discardCreatedRoom_empty", "p1")
+	joined, _ := GetOrCreateRoom("Test_discardCreatedRoom_joined", "p1")
+	defer deleteRoom(joined)
+	joined.Join(NewClient("p2", false, DefaultLanguage), false)
+
+	DiscardCreatedRoom(empty)
+	DiscardCreatedRoom(joined)
+	if GetRoom("Test_discardCreatedRoom_empty") != nil {
+		t.Errorf("the empty room should be deleted")
+	}
+	if GetRoom("Test_discardCreatedRoom_joined") == nil {
+		t.Errorf("the room joined in the meantime should be kept")
+	}
+}
+
+func Test_joinBroadcastsNewSeats(t *testing.T) {
+	room := newRoom(t.Name(), "p1", engine.MaxPlayers, engine.Options{}, nil)
+	defer room.close()
+	p1 := NewClient("p1", false, DefaultLanguage)
+	room.Join(p1, true)
+	// numAddPlayer returns the num of ADD_PLAYER queued for client, emptying the queue.
+	numAddPlayer := func(client *Client) int {
+		count := 0
+		for len(client.send) > 0 {
+			var response RawMessageResponse
+			json.Unmarshal(<-client.send, &response)
+			if response.Type == "ADD_PLAYER" {
+				count++
+			}
+		}
+		return count
+	}
+	numAddPlayer(p1)
+
+	room.Join(NewClient("p2", false, DefaultLanguage), false)
+	if count := numAddPlayer(p1); count != 1 {
+		t.Errorf("a new seat should be broadcast: %d", count)
+	}
+	spectator := NewClient("s1", true, DefaultLanguage)
+	room.Join(spectator, false)
+	room.Join(NewClient("p2", false, DefaultLanguage), false)
+	if count := numAddPlayer(p1); count != 0 {
+		t.Errorf("a spectator or a reconnect should not be broadcast: %d", count)
+	}
+	if count := numAddPlayer(spectator); count != 1 {
+		t.Errorf("the spectator should get the seats: %d", count)
+	}
+}
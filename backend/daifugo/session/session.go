@@ -0,0 +1,173 @@
+// Package session runs the rooms of daifugo.
+//
+// A Room owns a game of the engine package and the clients connected to it,
+// and speaks the message protocol with them: requests such as SUBMIT_CARDS
+// are passed to HandleMessage, and the responses are queued to each Client.
+// The transport, such as the WebSocket adapter, only moves the bytes.
+package session
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+)
+
+type JoinRejectedResponse struct {
+	Code ErrorCode `json:"code"`
+	Message string `json:"message"`
+}
+
+// CreateRoomResponse is returned only to the creator of the room,
+// because OwnerToken is required to delete the room.
+type CreateRoomResponse struct {
+	RoomInfo
+	OwnerToken string `json:"ownerToken"`
+	// InviteCode is set only for private rooms.
+	InviteCode string `json:"inviteCode,omitempty"`
+}
+
+// InvalidRequestError, InvalidParameterError, RoomNotFoundError, RoomAlreadyExistsError and
+// NotRoomOwnerError are the errors of the requests to the transport other than messages.
+
+func InvalidRequestError() *RequestError {
+	return newRequestError(InvalidMessage, textInvalidRequest)
+}
+
+func InvalidParameterError(name string, value string) *RequestError {
+	return newRequestError(InvalidMessage, textInvalidParameter, name, value)
+}
+
+func RoomNotFoundError(roomName string) *RequestError {
+	return newRequestError(RoomNotFound, textRoomNotFound, roomName)
+}
+
+func RoomAlreadyExistsError(roomName string) *RequestError {
+	return newRequestError(RoomAlreadyExists, textRoomAlreadyExists, roomName)
+}
+
+func NotRoomOwnerError() *RequestError {
+	return newRequestError(NotRoomOwner, textNotRoomOwner)
+}
+
+// Authorize checks whether playerName can join the room with the invite code or the passphrase.
+// It is checked again by Join, but the transport can reject the client early with it.
+func (room *Room) Authorize(playerName string, spectator bool, inviteCode string, passphrase string) *RequestError {
+	var joinError *RequestError
+	if !room.do(func(room *Room) {
+		if !room.canAccess(inviteCode, passphrase) {
+			joinError = newRequestError(AccessDenied, textAccessDenied)
+			return
+		}
+		joinError = room.canJoin(playerName, spectator)
+	}) {
+		return newRequestError(RoomClosed, textRoomClosed)
+	}
+	return joinError
+}
+
+// Join adds client to the room and sends the current state to it.
+// created reports whether the client has created the room, and it gets ROOM_CREATED then.
+// A rejected client gets JOIN_REJECTED. ADD_PLAYER is broadcast only when a seat is added,
+// and a spectator or a player coming back to the seat gets it alone.
+func (room *Room) Join(client *Client, created bool) *RequestError {
+	type AddPlayerDataResponse struct {
+		PlayerNames []string `json:"playerNames"`
+	}
+
+	joinError := newRequestError(RoomClosed, textRoomClosed)
+	room.do(func(room *Room) {
+		// the room may have changed since Authorize
+		playerName := client.playerName
+		if joinError = room.canJoin(playerName, client.spectator); joinError != nil {
+			client.enqueue(encodeResponse("JOIN_REJECTED", JoinRejectedResponse{
+				Code: joinError.Code,
+				Message: joinError.Message(client.language),
+			}))
+			return
+		}
+		seatAdded := false
+		if !client.spectator {
+			seatAdded = room.game.AddPlayer(playerName) == nil
+			// a player who has left comes back to the seat kept by a bot or held
+			room.returnToSeat(playerName)
+		}
+		room.addClient(client)
+		room.updateHost()
+		room.sendToPlayer(playerName, "LOCALE", localeFor(client))
+		room.sendToPlayer(playerName, "ROOM_STATE", room.roomState())
+		room.sendToPlayer(playerName, "CHAT_HISTORY", room.chatHistoryFor(client))
+		room.sendToPlayer(playerName, "GAME_SNAPSHOT", room.snapshot())
+		if created {
+			room.sendToPlayer(playerName, "ROOM_CREATED", CreateRoomResponse{
+				RoomInfo: room.info(),
+				OwnerToken: room.ownerToken,
+			})
+		}
+		playerNames := make([]string, len(room.game.Players))
+		for i, player := range room.game.Players {
+			playerNames[i] = player.Name	
+		}
+		response := AddPlayerDataResponse{
+			PlayerNames: playerNames,
+		}
+		if seatAdded {
+			room.broadcast("ADD_PLAYER", response)
+		} else {
+			// the others know the seats already
+			room.sendToPlayer(playerName, "ADD_PLAYER", response)
+		}
+	})
+	return joinError
+}
+
+// Leave removes client from the room. The seat of the player is kept,
+// and the game is paused when the turn comes to the player.
+func (room *Room) Leave(client *Client) {
+	room.do(func(room *Room) {
+		room.removeClient(client)
+	})
+}
+
+// HandleMessage handles a message sent by client.
+func (room *Room) HandleMessage(client *Client, message []byte) {
+	room.do(func(room *Room) {
+		handleWebsocketMessage(room, client.playerName, message)
+	})
+}
+
+// Credentials returns the metadata, the owner token and the invite code of the room
+// for its creator. ok is false if the room is closed.
+func (room *Room) Credentials() (response CreateRoomResponse, ok bool) {
+	ok = room.do(func(room *Room) {
+		response = CreateRoomResponse{
+			RoomInfo: room.info(),
+			OwnerToken: room.ownerToken,
+			InviteCode: room.inviteCode,
+		}
+	})
+	return response, ok
+}
+
+// CloseByOwner closes the room and disconnects its clients if ownerToken is the owner token of the room.
+func (room *Room) CloseByOwner(ownerToken string) bool {
+	isOwner := false
+	room.do(func(room *Room) {
+		isOwner = subtle.ConstantTimeCompare([]byte(room.ownerToken), []byte(ownerToken)) == 1
+		if isOwner {
+			room.broadcastText("ROOM_CLOSED", newText(textRoomClosed))
+		}
+	})
+	if isOwner {
+		deleteRoom(room)
+	}
+	return isOwner
+}
+
+// GameJSON returns the whole game including the hands, for debugging.
+// ok is false if the room is closed.
+func (room *Room) GameJSON() (snapshot []byte, ok bool) {
+	// marshal on the room goroutine to get a consistent snapshot
+	ok = room.do(func(room *Room) {
+		snapshot, _ = json.Marshal(room.game)
+	})
+	return snapshot, ok
+}
@@ -0,0 +1,158 @@
+package session
+
+import (
+	"slices"
+	"time"
+
+	"go-playground/daifugo/engine"
+)
+
+// Time for the other players to answer REQUEST_UNDO. The undo is not applied if some of them don't.
+const undoTimeout = 30 * time.Second
+
+// undoState is the last action which can be taken back.
+// Only the last action is kept, and it can be requested to undo only once.
+type undoState struct {
+	// playerName made the last action, and before is the game before it.
+	playerName string
+	before *engine.Game
+	// request is set while the other players are asked to approve the undo.
+	request *undoRequest
+}
+
+type undoRequest struct {
+	approvedBy []string
+	expiresAt time.Time
+	timer *time.Timer
+}
+
+type UndoResult string
+const (
+	UndoApplied UndoResult = "APPLIED"
+	UndoRejected UndoResult = "REJECTED"
+	UndoExpired UndoResult = "EXPIRED"
+)
+
+// UndoRequestedResponse is broadcast when an undo is requested and whenever a player approves it.
+type UndoRequestedResponse struct {
+	PlayerName string `json:"playerName"`
+	ApprovedBy []string `json:"approvedBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// UndoResolvedResponse is broadcast when a request of undo is applied or dropped.
+type UndoResolvedResponse struct {
+	PlayerName string `json:"playerName"`
+	Result UndoResult `json:"result"`
+	// RejectedBy is set when Result is REJECTED.
+	RejectedBy string `json:"rejectedBy,omitempty"`
+}
+
+// recordUndoPoint keeps before, the game before the action of playerName, to undo it later.
+func (room *Room) recordUndoPoint(playerName string, before *engine.Game) {
+	if room.disableUndo {
+		return
+	}
+	room.undo = undoState{playerName: playerName, before: before}
+}
+
+// requireNoUndoRequest rejects game actions while an undo is requested,
+// so that the players answer it on the game they see.
+func (room *Room) requireNoUndoRequest() error {
+	if room.undo.request != nil {
+		return newRequestError(UndoPending, textUndoPending)
+	}
+	return nil
+}
+
+// handleRequestUndo asks the other seated players to approve taking back the last action of playerName.
+func handleRequestUndo(room *Room, playerName string) error {
+	if room.disableUndo {
+		return newRequestError(UndoDisabled, textUndoDisabled)
+	}
+	if err := room.requireNoUndoRequest(); err != nil {
+		return err
+	}
+	// a finished game is not undone since its results have been published
+	if room.undo.before == nil || room.undo.playerName != playerName || room.game.GameState != engine.PlayingCards {
+		return newRequestError(NothingToUndo, textNothingToUndo)
+	}
+	request := &undoRequest{approvedBy: make([]string, 0), expiresAt: time.Now().Add(undoTimeout)}
+	request.timer = room.after(undoTimeout, func(room *Room) {
+		if room.undo.request == request {
+			room.resolveUndo(UndoExpired, "")
+		}
+	})
+	room.undo.request = request
+	room.broadcastUndoRequest()
+	return nil
+}
+
+// handleAnswerUndo approves or rejects the requested undo.
+// It is applied when all the others who can answer approve (see answersUndo).
+func handleAnswerUndo(room *Room, playerName string, approve bool) error {
+	request := room.undo.request
+	if request == nil {
+		return newRequestError(NoUndoRequest, textNoUndoRequest)
+	}
+	if room.game.Player(playerName) == nil {
+		return newRequestError(PlayerNotFound, textPlayerNotFound)
+	}
+	if playerName == room.undo.playerName {
+		return newRequestError(InvalidMessage, textCannotAnswerOwnUndo)
+	}
+	if !approve {
+		room.resolveUndo(UndoRejected, playerName)
+		return nil
+	}
+	if !slices.Contains(request.approvedBy, playerName) {
+		request.approvedBy = append(request.approvedBy, playerName)
+	}
+	for _, player := range room.game.Players {
+		if player.Name != room.undo.playerName && room.answersUndo(player.Name) && !slices.Contains(request.approvedBy, player.Name) {
+			room.broadcastUndoRequest()
+			return nil
+		}
+	}
+	before := room.undo.before
+	if room.game.GameState == engine.Paused {
+		// the game paused while waiting for the answers stays paused
+		before.Pause()
+	}
+	room.game = before
+	room.resolveUndo(UndoApplied, "")
+	return nil
+}
+
+// answersUndo reports whether playerName is asked to approve an undo.
+// A seat played by a bot or held, and a disconnected player, cannot answer it.
+func (room *Room) answersUndo(playerName string) bool {
+	return room.isPresent(playerName)
+}
+
+// clearUndo forgets the last action, dropping the request of undo if any.
+func (room *Room) clearUndo() {
+	if room.undo.request != nil {
+		room.resolveUndo(UndoExpired, "")
+	}
+	room.undo = undoState{}
+}
+
+func (room *Room) broadcastUndoRequest() {
+	room.broadcast("UNDO_REQUESTED", UndoRequestedResponse{
+		PlayerName: room.undo.playerName,
+		ApprovedBy: room.undo.request.approvedBy,
+		ExpiresAt: room.undo.request.expiresAt,
+	})
+}
+
+// resolveUndo ends the request of undo. The last action cannot be requested to undo again.
+func (room *Room) resolveUndo(result UndoResult, rejectedBy string) {
+	room.undo.request.timer.Stop()
+	room.broadcast("UNDO_RESOLVED", UndoResolvedResponse{
+		PlayerName: room.undo.playerName,
+		Result: result,
+		RejectedBy: rejectedBy,
+	})
+	room.undo = undoState{}
+}
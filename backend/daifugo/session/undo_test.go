@@ -0,0 +1,103 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func Test_undo(t *testing.T) {
+	room, first := startTestGame(t, nil)
+	// code returns the ErrorCode of err, or empty if it is not a RequestError.
+	code := func(err error) ErrorCode {
+		var requestError *RequestError
+		if errors.As(err, &requestError) {
+			return requestError.Code
+		}
+		return ""
+	}
+	room.do(func(room *Room) {
+		if err := handleRequestUndo(room, first); code(err) != NothingToUndo {
+			t.Errorf("nothing should be undone before any action: %v", err)
+		}
+		if err := handlePass(room, first); err != nil {
+			t.Fatalf("cannot pass: %v", err)
+		}
+		second := room.game.CurrentPlayer().Name
+		if err := handleRequestUndo(room, second); code(err) != NothingToUndo {
+			t.Errorf("only the last actor can request: %v", err)
+		}
+		if err := handleRequestUndo(room, first); err != nil {
+			t.Fatalf("cannot request: %v", err)
+		}
+		if err := handlePass(room, second); code(err) != UndoPending {
+			t.Errorf("actions should wait for the answers: %v", err)
+		}
+		if err := handlePassToPartner(room, second, json.RawMessage(`{"cards": []}`)); code(err) != UndoPending {
+			t.Errorf("passing to the partner should wait for the answers: %v", err)
+		}
+		if err := handleAnswerUndo(room, first, true); code(err) != InvalidMessage {
+			t.Errorf("the requester should not answer: %v", err)
+		}
+		if err := handleAnswerUndo(room, second, true); err != nil || room.game.CurrentPlayer().Name != second {
+			t.Errorf("undo should wait for all the others: %v", err)
+		}
+		for _, player := range room.game.Players {
+			if player.Name != first && player.Name != second {
+				handleAnswerUndo(room, player.Name, true)
+			}
+		}
+		if room.game.CurrentPlayer().Name != first || room.undo.request != nil {
+			t.Errorf("the pass should be undone: current is %s", room.game.CurrentPlayer().Name)
+		}
+		if err := room.game.CheckInvariants(); err != nil {
+			t.Errorf("invariant is broken by undo: %v", err)
+		}
+		if err := handleRequestUndo(room, first); code(err) != NothingToUndo {
+			t.Errorf("an undone action cannot be undone again: %v", err)
+		}
+
+		handlePass(room, first)
+		handleRequestUndo(room, first)
+		if err := handleAnswerUndo(room, second, false); err != nil || room.game.CurrentPlayer().Name != second {
+			t.Errorf("rejected undo should not be applied: %v", err)
+		}
+		if err := handleAnswerUndo(room, second, true); code(err) != NoUndoRequest {
+			t.Errorf("rejection should end the request: %v", err)
+		}
+
+		room.disableUndo = true
+		handlePass(room, second)
+		if err := handleRequestUndo(room, second); code(err) != UndoDisabled {
+			t.Errorf("undo should be disabled: %v", err)
+		}
+	})
+}
+
+func Test_undoWithHeldSeat(t *testing.T) {
+	room, first := startTestGame(t, leavePolicy(LeaveHoldSeat))
+	var second, third string
+	room.do(func(room *Room) {
+		handlePass(room, first)
+		second = room.game.CurrentPlayer().Name
+		for _, player := range room.game.Players {
+			if player.Name != first && player.Name != second {
+				third = player.Name
+			}
+		}
+	})
+	room.do(func(room *Room) {
+		handleRemovePlayer(room, third, json.RawMessage(`{"playerName": "` + third + `"}`))
+	})
+	room.do(func(room *Room) {
+		if _, ok := room.heldSeats[third]; !ok {
+			t.Fatalf("the seat should be held")
+		}
+		if err := handleRequestUndo(room, first); err != nil {
+			t.Fatalf("cannot request: %v", err)
+		}
+		if err := handleAnswerUndo(room, second, true); err != nil || room.game.CurrentPlayer().Name != first {
+			t.Errorf("the held seat should not be asked to approve: %v", err)
+		}
+	})
+}
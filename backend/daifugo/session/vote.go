@@ -0,0 +1,96 @@
+package session
+
+import (
+	"slices"
+
+	"go-playground/daifugo/engine"
+)
+
+// voteKind is what the seated players vote for. It passes with the majority of them.
+type voteKind string
+const (
+	votePause voteKind = "PAUSE"
+	voteResume voteKind = "RESUME"
+	voteAbort voteKind = "ABORT"
+	voteRedeal voteKind = "REDEAL"
+)
+
+// VoteResponse is broadcast when a player votes.
+// The vote passes when the num of Voters reaches Required.
+type VoteResponse struct {
+	Kind voteKind `json:"kind"`
+	Voters []string `json:"voters"`
+	Required int `json:"required"`
+}
+
+// majority returns the num of votes needed to pass, more than half of the seated players
+// who are present (see isPresent). Bots, held seats and disconnected players cannot vote.
+func (room *Room) majority() int {
+	numPresent := 0
+	for _, player := range room.game.Players {
+		if room.isPresent(player.Name) {
+			numPresent++
+		}
+	}
+	return numPresent/2 + 1
+}
+
+// vote records that playerName votes for kind, and reports whether the vote passes.
+// The votes for kind are cleared when it passes.
+func (room *Room) vote(kind voteKind, playerName string) (bool, error) {
+	if room.game.Player(playerName) == nil {
+		return false, newRequestError(PlayerNotFound, textPlayerNotFound)
+	}
+	voters := room.votes[kind]
+	if !slices.Contains(voters, playerName) {
+		voters = append(voters, playerName)
+	}
+	required := room.majority()
+	room.broadcast("VOTE", VoteResponse{Kind: kind, Voters: voters, Required: required})
+	if len(voters) < required {
+		room.votes[kind] = voters
+		return false, nil
+	}
+	delete(room.votes, kind)
+	return true, nil
+}
+
+// withdrawVotes removes the votes of playerName, who has left the seats.
+func (room *Room) withdrawVotes(playerName string) {
+	for kind, voters := range room.votes {
+		room.votes[kind] = slices.DeleteFunc(voters, func(voter string) bool {
+			return voter == playerName
+		})
+	}
+}
+
+// GameAbortedResponse is broadcast when the game is aborted by a vote.
+// A redeal is followed by GAME_START.
+type GameAbortedResponse struct {
+	Redeal bool `json:"redeal"`
+}
+
+// handleVoteAbort votes for aborting the game without its result, or for dealing the cards again.
+func handleVoteAbort(room *Room, playerName string, redeal bool) error {
+	if !room.game.InProgress() {
+		return engineError(engine.ErrGameNotInProgress)
+	}
+	kind := voteAbort
+	if redeal {
+		kind = voteRedeal
+	}
+	passed, err := room.vote(kind, playerName)
+	if err != nil || !passed {
+		return err
+	}
+	if err := room.game.Abort(); err != nil {
+		return engineError(err)
+	}
+	room.clearUndo()
+	clear(room.votes)
+	room.broadcast("GAME_ABORTED", GameAbortedResponse{Redeal: redeal})
+	if redeal {
+		return room.startGame()
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go-playground/daifugo/engine"
+)
+
+func Test_voteAbort(t *testing.T) {
+	room := newTestRoom(t, nil)
+	room.do(func(room *Room) {
+		if err := handleVoteAbort(room, "p1", false); err == nil {
+			t.Errorf("a game not started cannot be aborted")
+		}
+		handleGameStart(room, "p1", json.RawMessage(`{"force": true}`))
+
+		handleVoteAbort(room, "p1", true)
+		handleVoteAbort(room, "p1", true)
+		if !room.game.InProgress() || len(room.votes[voteRedeal]) != 1 {
+			t.Errorf("a player should vote once: %v", room.votes)
+		}
+		handleVoteAbort(room, "p2", false)
+		if err := handleVoteAbort(room, "p2", true); err != nil || room.game.GameState != engine.PlayingCards || len(room.votes) != 0 {
+			t.Errorf("the majority should redeal: %v %s %v", err, room.game.GameState, room.votes)
+		}
+		if len(room.game.Results) != 0 {
+			t.Errorf("aborted game should not have a result: %v", room.game.Results)
+		}
+
+		handleVoteAbort(room, "p2", false)
+		handleVoteAbort(room, "p3", false)
+		if room.game.GameState != engine.WaitingForPlayers || len(room.game.Results) != 0 {
+			t.Errorf("the majority should abort: %s", room.game.GameState)
+		}
+		if err := room.game.CheckInvariants(); err != nil {
+			t.Errorf("invariant is broken: %v", err)
+		}
+	})
+}
+
+func Test_voteMajorityOfPresentPlayers(t *testing.T) {
+	room, _ := startTestGame(t, nil)
+	room.do(func(room *Room) {
+		room.removeClient(room.clients["p3"])
+		room.bots["p2"] = struct{}{}
+		if required := room.majority(); required != 1 {
+			t.Errorf("only p1 is present, so p1 should be the majority: %d", required)
+		}
+		if err := handleVoteAbort(room, "p1", false); err != nil || room.game.InProgress() {
+			t.Errorf("the vote of p1 should abort: %v %s", err, room.game.GameState)
+		}
+	})
+}
+
+func Test_removeSeatedPlayerInGame(t *testing.T) {
+	room, first := startTestGame(t, nil)
+	room.do(func(room *Room) {
+		handlePass(room, first)
+		handleRequestUndo(room, first)
+		handleVoteAbort(room, first, false)
+
+		if err := handleRemovePlayer(room, first, json.RawMessage(`{"playerName": "` + first + `"}`)); err != nil {
+			t.Fatalf("a player should leave in the middle of a game: %v", err)
+		}
+		if room.game.Player(first) != nil || room.undo.request != nil || len(room.votes[voteAbort]) != 0 {
+			t.Errorf("the player, the undo and the votes should be removed: %+v %v", room.undo, room.votes)
+		}
+		if err := room.game.CheckInvariants(); err != nil {
+			t.Errorf("invariant is broken: %v", err)
+		}
+		if !room.game.InProgress() {
+			t.Errorf("the game should go on with two players: %s", room.game.GameState)
+		}
+	})
+}
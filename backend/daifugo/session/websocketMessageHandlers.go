@@ -0,0 +1,432 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"go-playground/daifugo/engine"
+)
+
+type Message struct {
+	Type string      `json:"type"`
+	PlayerName string `json:"playerName"`
+	// RequestID is optional. When it is set, the result of the request is
+	// replied to the sender as ACK or NACK with the same RequestID.
+	RequestID string `json:"requestId"`
+	Data json.RawMessage `json:"data"`
+}
+type ErrorData struct {
+	Message string `json:"message"`
+}
+
+// ErrorCode is a machine-readable reason sent in NACK responses.
+type ErrorCode string
+const (
+	InvalidMessage ErrorCode = "INVALID_MESSAGE"
+	UnknownMessageType ErrorCode = "UNKNOWN_MESSAGE_TYPE"
+	GameNotInProgress ErrorCode = "GAME_NOT_IN_PROGRESS"
+	NotYourTurn ErrorCode = "NOT_YOUR_TURN"
+	CannotSubmitCards ErrorCode = "CANNOT_SUBMIT_CARDS"
+	PlayerNotFound ErrorCode = "PLAYER_NOT_FOUND"
+	CannotStartGame ErrorCode = "CANNOT_START_GAME"
+	RoomNotFound ErrorCode = "ROOM_NOT_FOUND"
+	RoomAlreadyExists ErrorCode = "ROOM_ALREADY_EXISTS"
+	RoomFull ErrorCode = "ROOM_FULL"
+	GameInProgress ErrorCode = "GAME_IN_PROGRESS"
+	RoomClosed ErrorCode = "ROOM_CLOSED"
+	NotRoomOwner ErrorCode = "NOT_ROOM_OWNER"
+	NameAlreadyUsed ErrorCode = "NAME_ALREADY_USED"
+	AccessDenied ErrorCode = "ACCESS_DENIED"
+	NotHost ErrorCode = "NOT_HOST"
+	RoomLocked ErrorCode = "ROOM_LOCKED"
+	Kicked ErrorCode = "KICKED"
+	NotAllReady ErrorCode = "NOT_ALL_READY"
+	MessageTooLong ErrorCode = "MESSAGE_TOO_LONG"
+	RateLimited ErrorCode = "RATE_LIMITED"
+	SpectatorCannotPlay ErrorCode = "SPECTATOR_CANNOT_PLAY"
+	// The codes of the rule violations reported by the engine. See engine.RuleError.
+	NoCards ErrorCode = "NO_CARDS"
+	CardNotInHand ErrorCode = "CARD_NOT_IN_HAND"
+	InvalidJoker ErrorCode = "INVALID_JOKER"
+	InvalidCard ErrorCode = "INVALID_CARD"
+	MixedRanks ErrorCode = "MIXED_RANKS"
+	CountMismatch ErrorCode = "COUNT_MISMATCH"
+	TooWeak ErrorCode = "TOO_WEAK"
+	SuitLocked ErrorCode = "SUIT_LOCKED"
+	NotPassingToPartner ErrorCode = "NOT_PASSING_TO_PARTNER"
+	AlreadyPassedToPartner ErrorCode = "ALREADY_PASSED_TO_PARTNER"
+	TooManyCards ErrorCode = "TOO_MANY_CARDS"
+	NothingToUndo ErrorCode = "NOTHING_TO_UNDO"
+	UndoPending ErrorCode = "UNDO_PENDING"
+	UndoDisabled ErrorCode = "UNDO_DISABLED"
+	NoUndoRequest ErrorCode = "NO_UNDO_REQUEST"
+	GamePaused ErrorCode = "GAME_PAUSED"
+	NotPaused ErrorCode = "NOT_PAUSED"
+	PlayerAway ErrorCode = "PLAYER_AWAY"
+	SeatHeld ErrorCode = "SEAT_HELD"
+)
+
+// RequestError is returned by message handlers when a request is rejected.
+// Text is a human-readable text which can be shown to the user as it is
+// once it is translated by Message.
+type RequestError struct {
+	Code ErrorCode
+	Text Text
+}
+
+func (err *RequestError) Error() string {
+	return string(err.Code) + ": " + err.Text.Message(engine.English)
+}
+
+// Message returns the text of the error in language.
+func (err *RequestError) Message(language engine.Language) string {
+	return err.Text.Message(language)
+}
+
+func newRequestError(code ErrorCode, key textKey, args ...any) *RequestError {
+	return &RequestError{Code: code, Text: newText(key, args...)}
+}
+
+// engineError converts an error of the engine to a RequestError with the same code.
+// The errors of starting a game are reported as CannotStartGame.
+func engineError(err error) *RequestError {
+	var ruleError *engine.RuleError
+	if !errors.As(err, &ruleError) {
+		return newRequestError(CannotSubmitCards, textCannotSubmitCards)
+	}
+	if errors.Is(err, engine.ErrNotEnoughPlayers) || errors.Is(err, engine.ErrTooManyPlayers) ||
+		errors.Is(err, engine.ErrInvalidDecks) || errors.Is(err, engine.ErrInvalidTeams) {
+		return newRequestError(CannotStartGame, textCannotStartGame, ruleError)
+	}
+	return newRequestError(ErrorCode(ruleError.Code), textPlain, ruleError)
+}
+
+type AckResponse struct {
+	RequestID string `json:"requestId"`
+	RequestType string `json:"requestType"`
+}
+
+type NackResponse struct {
+	RequestID string `json:"requestId"`
+	RequestType string `json:"requestType"`
+	Code ErrorCode `json:"code"`
+	Message string `json:"message"`
+}
+
+func parseMessageTypeAndPlayerName(rawJsonBytes []byte) (Message, error) {
+	var msg Message
+	err := json.Unmarshal(rawJsonBytes, &msg)
+	if err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+func handlePass(room *Room, playerName string) error {
+	fmt.Println("handlePass")
+	if err := room.requireNoUndoRequest(); err != nil {
+		return err
+	}
+	before := room.game.Clone()
+	if err := room.game.Pass(playerName); err != nil {
+		return engineError(err)
+	}
+	room.gameEvents.record(GameEvent{Type: Passed, PlayerName: playerName})
+	room.recordUndoPoint(playerName, before)
+	return nil
+}
+
+type RawMessageResponse struct {
+	Type string `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type RemovePlayerDataRequest struct {
+	PlayerName string `json:"playerName"`
+}
+
+type RemovePlayerDataResponse struct {
+	PlayerName string `json:"playerName"`
+}
+
+// handleRemovePlayer removes a player from the game.
+// Players can remove themselves, and only the host can remove others.
+func handleRemovePlayer(room *Room, playerName string, data json.RawMessage) error {
+	fmt.Println("handleRemovePlayer")
+	var removePlayerDataRequest RemovePlayerDataRequest 
+	if err := json.Unmarshal(data, &removePlayerDataRequest); err != nil {
+		return newRequestError(InvalidMessage, textInvalidRequest)
+	}
+	if removePlayerDataRequest.PlayerName != playerName {
+		if err := room.requireHost(playerName); err != nil {
+			return err
+		}
+	}
+	// a player who leaves in the middle of a game forfeits or keeps the seat by the leave policy
+	return room.removeSeatedPlayer(removePlayerDataRequest.PlayerName)
+}
+
+type GameStartRequest struct {
+	// Force starts the game even if some players are not ready.
+	Force bool `json:"force"`
+}
+
+// GameStartResponse notifies that a game has started.
+// Hand cards are sent by PLAYER_STATE.
+type GameStartResponse struct {
+	Players []engine.PublicPlayer `json:"players"`
+}
+
+// handleReady marks playerName as ready or not ready for the next game.
+func handleReady(room *Room, playerName string, ready bool) error {
+	if err := room.requireNotPlaying(); err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(room.game.Players, func(player *engine.Player) bool {
+		return player.Name == playerName
+	}) {
+		return newRequestError(PlayerNotFound, textPlayerNotFound)
+	}
+	if ready {
+		room.ready[playerName] = struct{}{}
+	} else {
+		delete(room.ready, playerName)
+	}
+	room.broadcast("ROOM_STATE", room.roomState())
+	return nil
+}
+
+// handleGameStart starts the game when all seated players are ready.
+// The host can force it with {"force": true}.
+func handleGameStart(room *Room, playerName string, data json.RawMessage) error {
+	fmt.Println("handleGameStart")
+	var gameStartRequest GameStartRequest
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &gameStartRequest); err != nil {
+			return newRequestError(InvalidMessage, textInvalidRequest)
+		}
+	}
+	if err := room.requireHost(playerName); err != nil {
+		return err
+	}
+	if err := room.requireNotPlaying(); err != nil {
+		return err
+	}
+	if err := room.requireNoUndoRequest(); err != nil {
+		return err
+	}
+	game := room.game
+	if !gameStartRequest.Force {
+		notReady := make([]string, 0)
+		for _, player := range game.Players {
+			if _, ok := room.ready[player.Name]; !ok {
+				notReady = append(notReady, player.Name)
+			}
+		}
+		if len(notReady) > 0 {
+			return newRequestError(NotAllReady, textNotAllReady, strings.Join(notReady, ", "))
+		}
+	}
+	return room.startGame()
+}
+
+// startGame deals the cards and notifies everyone.
+func (room *Room) startGame() error {
+	if err := room.game.Start(); err != nil {
+		return engineError(err)
+	}
+	// everyone has to be ready again for the next game
+	clear(room.ready)
+	// the last game cannot be undone any more
+	room.clearUndo()
+	clear(room.votes)
+	room.broadcast("ROOM_STATE", room.roomState())
+	room.broadcast("GAME_START", GameStartResponse{
+		Players: room.game.PublicPlayers(),
+	})
+	return nil
+}
+
+
+type SubmitCardsRequest struct {
+	PlayerName string `json:"playerName"`
+	// Cards are CardStr such as "3S" and "JOKER", or Card objects.
+	// Only the number and the type of the objects are used. See parseSubmittedCards.
+	Cards []json.RawMessage `json:"cards"`
+}
+
+type MessageResponse struct{
+	Message string `json:"message"`
+	// Code is set when the message reports a rejected request without a requestId.
+	Code ErrorCode `json:"code,omitempty"`
+}
+
+func handleSubmitCards(room *Room, playerName string, data json.RawMessage) error {
+	fmt.Println("handleSubmitCards")
+	var submitCardsRequest SubmitCardsRequest 
+	if err := json.Unmarshal(data, &submitCardsRequest); err != nil {
+		return newRequestError(InvalidMessage, textInvalidRequest)
+	}
+	game := room.game
+	if game.GameState == engine.Paused {
+		return engineError(engine.ErrGamePaused)
+	}
+	if game.GameState != engine.PlayingCards {
+		return engineError(engine.ErrGameNotInProgress)
+	}
+	submittedPlayer := game.Player(playerName)
+	if submittedPlayer == nil {
+		return engineError(engine.ErrPlayerNotFound)
+	}
+	cards, err := parseSubmittedCards(submitCardsRequest.Cards, submittedPlayer.Cards)
+	if err != nil {
+		return engineError(err)
+	}
+	if err := room.requireNoUndoRequest(); err != nil {
+		return err
+	}
+	before := game.Clone()
+	if _, err := game.Submit(playerName, cards); err != nil {
+		log.Printf("%s cannot submit %v: %v", playerName, cards, err)
+		return engineError(err)
+	}
+	room.gameEvents.record(GameEvent{Type: CardsPlayed, PlayerName: playerName, Cards: cards})
+	room.recordUndoPoint(playerName, before)
+	return nil
+}
+
+type PassToPartnerRequest struct {
+	// Cards are in the same format as SubmitCardsRequest. No cards means passing nothing.
+	Cards []json.RawMessage `json:"cards"`
+}
+
+// handlePassToPartner passes cards to the partner at the start of a team game.
+// The hands are sent by PLAYER_STATE when every player has passed.
+func handlePassToPartner(room *Room, playerName string, data json.RawMessage) error {
+	var request PassToPartnerRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return newRequestError(InvalidMessage, textInvalidRequest)
+	}
+	player := room.game.Player(playerName)
+	if player == nil {
+		return engineError(engine.ErrPlayerNotFound)
+	}
+	cards, err := parseSubmittedCards(request.Cards, player.Cards)
+	if err != nil {
+		return engineError(err)
+	}
+	if err := room.requireNoUndoRequest(); err != nil {
+		return err
+	}
+	if err := room.game.PassToPartner(playerName, cards); err != nil {
+		return engineError(err)
+	}
+	return nil
+}
+
+// handleWebsocketMessage handles a message sent by playerName.
+// If the message has a requestId, ACK or NACK is replied to the sender.
+// Otherwise a rejected request is notified by MESSAGE as before.
+func handleWebsocketMessage(room *Room, playerName string, rawMessage []byte) {
+	fmt.Println("handleWebsocketMessage")
+	message, err := parseMessageTypeAndPlayerName(rawMessage)
+	if err != nil {
+		log.Printf("MessageType parse error: %v", err)
+		room.sendToPlayer(playerName, "NACK", NackResponse{
+			Code: InvalidMessage,
+			Message: newText(textInvalidRequest).Message(room.languageOf(playerName)),
+		})
+		return
+	}
+	
+	// spectators can only chat and sync
+	if client, ok := room.clients[playerName]; ok && client.spectator && message.Type != "CHAT" && message.Type != "SYNC" {
+		replyResult(room, playerName, message, newRequestError(SpectatorCannotPlay, textSpectatorCannotPlay))
+		return
+	}
+
+	switch message.Type {
+	case "REMOVE_PLAYER": 
+		err = handleRemovePlayer(room, playerName, message.Data)
+	case "GAME_START": 
+		err = handleGameStart(room, playerName, message.Data)
+	case "READY":
+		err = handleReady(room, playerName, true)
+	case "UNREADY":
+		err = handleReady(room, playerName, false)
+	case "SUBMIT_CARDS": 
+		err = handleSubmitCards(room, playerName, message.Data)
+	case "PASS":
+		err = handlePass(room, playerName)
+	case "PASS_TO_PARTNER":
+		err = handlePassToPartner(room, playerName, message.Data)
+	case "PAUSE":
+		err = handlePause(room, playerName)
+	case "RESUME":
+		err = handleResume(room, playerName)
+	case "VOTE_ABORT":
+		err = handleVoteAbort(room, playerName, false)
+	case "VOTE_REDEAL":
+		err = handleVoteAbort(room, playerName, true)
+	case "REQUEST_UNDO":
+		err = handleRequestUndo(room, playerName)
+	case "APPROVE_UNDO":
+		err = handleAnswerUndo(room, playerName, true)
+	case "REJECT_UNDO":
+		err = handleAnswerUndo(room, playerName, false)
+	case "CHAT":
+		err = handleChat(room, playerName, message.Data)
+	case "SYNC":
+		err = handleSync(room, playerName, message.Data)
+	case "KICK_PLAYER":
+		err = handleKickPlayer(room, playerName, message.Data)
+	case "TRANSFER_HOST":
+		err = handleTransferHost(room, playerName, message.Data)
+	case "LOCK_ROOM":
+		err = handleLockRoom(room, playerName, true)
+	case "UNLOCK_ROOM":
+		err = handleLockRoom(room, playerName, false)
+	case "REORDER_SEATS":
+		err = handleReorderSeats(room, playerName, message.Data)
+	case "SET_RULES":
+		err = handleSetRules(room, playerName, message.Data)
+	default:
+		log.Printf("unknown message type: %s", message.Type)
+		if message.RequestID == "" {
+			return
+		}
+		err = newRequestError(UnknownMessageType, textUnknownRequest, message.Type)
+	}
+	replyResult(room, playerName, message, err)
+}
+
+func replyResult(room *Room, playerName string, message Message, err error) {
+	if err == nil {
+		if message.RequestID != "" {
+			room.sendToPlayer(playerName, "ACK", AckResponse{
+				RequestID: message.RequestID,
+				RequestType: message.Type,
+			})
+		}
+		return
+	}
+	var requestError *RequestError
+	if !errors.As(err, &requestError) {
+		requestError = newRequestError(InvalidMessage, textPlain, err.Error())
+	}
+	language := room.languageOf(playerName)
+	if message.RequestID == "" {
+		room.sendToPlayer(playerName, "MESSAGE", MessageResponse{Message: requestError.Message(language), Code: requestError.Code})
+		return
+	}
+	room.sendToPlayer(playerName, "NACK", NackResponse{
+		RequestID: message.RequestID,
+		RequestType: message.Type,
+		Code: requestError.Code,
+		Message: requestError.Message(language),
+	})
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-playground/daifugo/engine"
+)
+
+func Test_parseMessageType(t *testing.T) {
+	type args = string
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"pass", `
+		{
+			"type": "pass",
+			"data": {
+			}
+		}`, "pass"},
+		{"submitCard", `
+		{
+			"type": "submitCard",
+			"data": {
+				"cards": ["3S", "4D"]
+			}
+		}`, "submitCard"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMessageTypeAndPlayerName([]byte(tt.args))
+			if err != nil {
+				t.Fatalf("%s failed with error:'%v'", tt.name, err)
+			}
+			if got.Type != tt.want {
+				t.Errorf("%s failed. got %v, want %v", tt.name, got.Type, tt.want)
+			}
+		})
+	}
+}
+
+func Test_engineError(t *testing.T) {
+	for _, tt := range []struct {
+		err error
+		want ErrorCode
+	}{
+		{engine.ErrNotYourTurn, NotYourTurn},
+		{engine.ErrTooWeak, TooWeak},
+		{engine.ErrCountMismatch, CountMismatch},
+		{fmt.Errorf("%w: only jokers can substitute", engine.ErrInvalidJoker), InvalidJoker},
+		{engine.ErrNotEnoughPlayers, CannotStartGame},
+		{fmt.Errorf("%w: 0", engine.ErrInvalidDecks), CannotStartGame},
+		{fmt.Errorf("%w: 3X", engine.ErrInvalidCard), InvalidCard},
+		{errors.New("unknown"), CannotSubmitCards},
+	} {
+		requestError := engineError(tt.err)
+		if requestError.Code != tt.want || requestError.Message(engine.English) == "" {
+			t.Errorf("%v should be converted to %s: %+v", tt.err, tt.want, requestError)
+		}
+	}
+}
+
+func FuzzHandleWebsocketMessage(f *testing.F) {
+	for _, message := range []string{
+		`{"type": "PASS"}`,
+		`{"type": "SUBMIT_CARDS", "requestId": "r1", "data": {"cards": ["3S", "JOKER:3H"]}}`,
+		`{"type": "SUBMIT_CARDS", "data": {"cards": [{"number": 3, "value": 99, "cardType": "Spade"}]}}`,
+		`{"type": "SUBMIT_CARDS", "data": {"cards": []}}`,
+		`{"type": "GAME_START", "data": {"force": true}}`,
+		`{"type": "REMOVE_PLAYER", "data": {"playerName": "p2"}}`,
+		`{"type": "REORDER_SEATS", "data": {"playerNames": ["p2", "p1"]}}`,
+		`{"type": "SYNC", "data": {"lastSeq": -1}}`,
+		`{"type": "PASS_TO_PARTNER", "data": {"cards": ["3S"]}}`,
+		`{"type": "PAUSE"}`,
+		`{"type": "RESUME"}`,
+		`{"type": "VOTE_ABORT"}`,
+		`{"type": "VOTE_REDEAL"}`,
+		`{"type": "REQUEST_UNDO"}`,
+		`{"type": "APPROVE_UNDO"}`,
+		`{"type": "REJECT_UNDO"}`,
+		`{"type": "CHAT", "data": {"stamp": "GG"}}`,
+		`{"type": "KICK_PLAYER", "data": null}`,
+		`not json`,
+	} {
+		f.Add(message, true)
+		f.Add(message, false)
+	}
+	f.Fuzz(func(t *testing.T, message string, byHost bool) {
+		room, playerName := startTestGame(t, func(room *Room) {
+			room.private = true
+		})
+		if byHost {
+			playerName = "p1"
+		}
+		room.do(func(room *Room) {
+			handleWebsocketMessage(room, playerName, []byte(message))
+			if err := room.game.CheckInvariants(); err != nil {
+				t.Fatalf("invariant is broken by %q: %v", message, err)
+			}
+		})
+	})
+}
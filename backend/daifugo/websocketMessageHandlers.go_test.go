@@ -1,39 +1,187 @@
 package daifugo
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"go-playground/daifugo/engine"
+	"go-playground/daifugo/session"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
-func Test_hoge(t *testing.T) {
-	type args = string
-	tests := []struct {
-		name string
-		args args
-		want string
-	}{
-		{"pass", `
-		{
-			"type": "pass",
-			"data": {
-			}
-		}`, "pass"},
-		{"pass", `
-		{
-			"type": "submitCard",
-			"data": {
-				"cards": ["3S", "4D"]
-			}
-		}`, "submitCard"},
-	}
-	/*
-	for _, tt := range tests {
-		
-		t.Run(tt.name, func(t *testing.T) {
-			if got, reason := parseMessageTypeAndPlayerName(tt.args); got != tt.want {
-				t.Errorf("%s failed with reason:'%s'. got %v, want %v", tt.name, reason, got, tt.want)
-			}
-		})
-			
-	}
-		*/
-}
\ No newline at end of file
+func startTestServer(t *testing.T) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/daifugo/debug/rooms/:roomName", DebugGetGameState)
+	router.GET("/daifugo/rooms", ListRoomsHandler)
+	router.GET("/daifugo/lobby/events", LobbyEventsHandler)
+	router.GET("/daifugo/ws/rooms/:roomName/:playerName", WebSocketDaifugoHandler)
+	router.POST("/daifugo/rooms/:roomName", CreateRoomHandler)
+	router.DELETE("/daifugo/rooms/:roomName", DeleteRoomHandler)
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		server.Close()
+		session.CloseAllRooms()
+	})
+	return server
+}
+
+func dialPlayer(t *testing.T, server *httptest.Server, roomName, playerName string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/daifugo/ws/rooms/" + roomName + "/" + playerName
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readUntil reads messages from conn and returns the first one of responseType.
+func readUntil(t *testing.T, conn *websocket.Conn, responseType string) session.RawMessageResponse {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read error while waiting for %s: %v", responseType, err)
+		}
+		var response session.RawMessageResponse
+		if err := json.Unmarshal(message, &response); err != nil {
+			t.Fatalf("unmarshal error: %v", err)
+		}
+		if response.Type == responseType {
+			return response
+		}
+	}
+}
+
+func sendMessage(t *testing.T, conn *websocket.Conn, message string) {
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+}
+
+func Test_ackAndNack(t *testing.T) {
+	server := startTestServer(t)
+	p1 := dialPlayer(t, server, "Test_ackAndNack", "p1")
+	readUntil(t, p1, "ADD_PLAYER")
+	p2 := dialPlayer(t, server, "Test_ackAndNack", "p2")
+	readUntil(t, p2, "ADD_PLAYER")
+
+	sendMessage(t, p1, `{"type": "PASS", "requestId": "r1", "data": {}}`)
+	var nack session.NackResponse
+	json.Unmarshal(readUntil(t, p1, "NACK").Data, &nack)
+	if nack.RequestID != "r1" || nack.RequestType != "PASS" || nack.Code != session.GameNotInProgress {
+		t.Errorf("unexpected NACK: %+v", nack)
+	}
+
+	sendMessage(t, p1, `{"type": "READY"}`)
+	sendMessage(t, p2, `{"type": "READY"}`)
+	sendMessage(t, p1, `{"type": "GAME_START", "requestId": "r2"}`)
+	var gameStart session.GameStartResponse
+	json.Unmarshal(readUntil(t, p1, "GAME_START").Data, &gameStart)
+	var ack session.AckResponse
+	json.Unmarshal(readUntil(t, p1, "ACK").Data, &ack)
+	if ack.RequestID != "r2" || ack.RequestType != "GAME_START" {
+		t.Errorf("unexpected ACK: %+v", ack)
+	}
+
+	current, other := p1, p2
+	if gameStart.Players[0].Name != "p1" {
+		current, other = p2, p1
+	}
+	sendMessage(t, other, `{"type": "PASS", "requestId": "r3", "data": {}}`)
+	json.Unmarshal(readUntil(t, other, "NACK").Data, &nack)
+	if nack.RequestID != "r3" || nack.Code != session.NotYourTurn {
+		t.Errorf("unexpected NACK: %+v", nack)
+	}
+
+	sendMessage(t, current, `{"type": "PASS", "requestId": "r4", "data": {}}`)
+	json.Unmarshal(readUntil(t, current, "ACK").Data, &ack)
+	if ack.RequestID != "r4" || ack.RequestType != "PASS" {
+		t.Errorf("unexpected ACK: %+v", ack)
+	}
+
+	sendMessage(t, current, `{"type": "UNKNOWN", "requestId": "r5"}`)
+	json.Unmarshal(readUntil(t, current, "NACK").Data, &nack)
+	if nack.RequestID != "r5" || nack.Code != session.UnknownMessageType {
+		t.Errorf("unexpected NACK: %+v", nack)
+	}
+}
+
+func Test_readyCheck(t *testing.T) {
+	server := startTestServer(t)
+	p1 := dialPlayer(t, server, "Test_readyCheck", "p1")
+	readUntil(t, p1, "ADD_PLAYER")
+
+	sendMessage(t, p1, `{"type": "READY"}`)
+	sendMessage(t, p1, `{"type": "GAME_START", "requestId": "r1"}`)
+	var nack session.NackResponse
+	json.Unmarshal(readUntil(t, p1, "NACK").Data, &nack)
+	if nack.Code != session.CannotStartGame {
+		t.Errorf("error of Start should be reported: %+v", nack)
+	}
+
+	p2 := dialPlayer(t, server, "Test_readyCheck", "p2")
+	readUntil(t, p2, "ADD_PLAYER")
+	sendMessage(t, p1, `{"type": "GAME_START", "requestId": "r2"}`)
+	json.Unmarshal(readUntil(t, p1, "NACK").Data, &nack)
+	if nack.Code != session.NotAllReady || !strings.Contains(nack.Message, "p2") {
+		t.Errorf("should wait for p2: %+v", nack)
+	}
+
+	sendMessage(t, p2, `{"type": "READY"}`)
+	sendMessage(t, p2, `{"type": "UNREADY"}`)
+	sendMessage(t, p1, `{"type": "GAME_START", "requestId": "r3"}`)
+	json.Unmarshal(readUntil(t, p1, "NACK").Data, &nack)
+	if nack.Code != session.NotAllReady {
+		t.Errorf("should wait for p2 again: %+v", nack)
+	}
+
+	sendMessage(t, p1, `{"type": "GAME_START", "requestId": "r4", "data": {"force": true}}`)
+	readUntil(t, p2, "GAME_START")
+	sendMessage(t, p1, `{"type": "GAME_START", "requestId": "r5", "data": {"force": true}}`)
+	json.Unmarshal(readUntil(t, p1, "NACK").Data, &nack)
+	if nack.RequestID != "r5" || nack.Code != session.GameInProgress {
+		t.Errorf("game in progress should not be restarted: %+v", nack)
+	}
+}
+
+func Test_language(t *testing.T) {
+	server := startTestServer(t)
+	p1 := dialPlayer(t, server, "Test_language", "p1?lang=en")
+	var locale session.LocaleResponse
+	json.Unmarshal(readUntil(t, p1, "LOCALE").Data, &locale)
+	if locale.Language != engine.English || locale.RoleNames[engine.Daifugo] != "Grand Millionaire" {
+		t.Errorf("unexpected locale: %+v", locale)
+	}
+	sendMessage(t, p1, `{"type": "PASS", "requestId": "r1"}`)
+	var nack session.NackResponse
+	json.Unmarshal(readUntil(t, p1, "NACK").Data, &nack)
+	if nack.Message != engine.ErrGameNotInProgress.Message(engine.English) {
+		t.Errorf("NACK should be in English: %+v", nack)
+	}
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/daifugo/ws/rooms/Test_language/p2"
+	p2, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Accept-Language": {"en-US,en;q=0.9,ja;q=0.8"}})
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer p2.Close()
+	json.Unmarshal(readUntil(t, p2, "LOCALE").Data, &locale)
+	if locale.Language != engine.English {
+		t.Errorf("Accept-Language should be used: %+v", locale)
+	}
+	sendMessage(t, p2, `{"type": "PASS"}`)
+	var message session.MessageResponse
+	json.Unmarshal(readUntil(t, p2, "MESSAGE").Data, &message)
+	if message.Code != session.GameNotInProgress || message.Message != engine.ErrGameNotInProgress.Message(engine.English) {
+		t.Errorf("MESSAGE should be in English: %+v", message)
+	}
+}
@@ -0,0 +1,82 @@
+package daifugo
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go-playground/daifugo/session"
+)
+
+func Test_chat(t *testing.T) {
+	server := startTestServer(t)
+	roomName := "Test_chat"
+	p1 := dialPlayer(t, server, roomName, "p1")
+	readUntil(t, p1, "CHAT_HISTORY")
+
+	sendMessage(t, p1, `{"type": "CHAT", "data": {"text": "hello"}}`)
+	sendMessage(t, p1, `{"type": "CHAT", "data": {"stamp": "GG"}}`)
+	var chatMessage session.ChatMessage
+	json.Unmarshal(readUntil(t, p1, "CHAT").Data, &chatMessage)
+	if chatMessage.PlayerName != "p1" || chatMessage.Text != "hello" || chatMessage.Channel != session.PlayersChannel {
+		t.Errorf("unexpected chat: %+v", chatMessage)
+	}
+
+	spectator := dialPlayer(t, server, roomName, "s1?spectator=true")
+	var history session.ChatHistoryResponse
+	json.Unmarshal(readUntil(t, spectator, "CHAT_HISTORY").Data, &history)
+	if len(history.Messages) != 2 || history.Messages[1].Stamp != "GG" {
+		t.Errorf("history should be sent on join: %+v", history)
+	}
+
+	sendMessage(t, spectator, `{"type": "CHAT", "data": {"text": "p1 has a joker"}}`)
+	json.Unmarshal(readUntil(t, spectator, "CHAT").Data, &chatMessage)
+	if chatMessage.Channel != session.SpectatorsChannel {
+		t.Errorf("spectator message should be in the spectators channel: %+v", chatMessage)
+	}
+	sendMessage(t, p1, `{"type": "CHAT", "data": {"text": "anyone?"}}`)
+	for {
+		json.Unmarshal(readUntil(t, p1, "CHAT").Data, &chatMessage)
+		if chatMessage.Channel == session.SpectatorsChannel {
+			t.Fatalf("players should not read the spectators channel")
+		}
+		if chatMessage.Text == "anyone?" {
+			break
+		}
+	}
+
+	p2 := dialPlayer(t, server, roomName, "p2")
+	json.Unmarshal(readUntil(t, p2, "CHAT_HISTORY").Data, &history)
+	for _, message := range history.Messages {
+		if message.Channel == session.SpectatorsChannel {
+			t.Errorf("history of players should not contain the spectators channel")
+		}
+	}
+
+	for _, tt := range []struct {
+		message string
+		code session.ErrorCode
+	}{
+		{`{"type": "CHAT", "requestId": "r", "data": {"text": "` + strings.Repeat("あ", session.MaxChatLength+1) + `"}}`, session.MessageTooLong},
+		{`{"type": "CHAT", "requestId": "r", "data": {"stamp": "UNKNOWN"}}`, session.InvalidMessage},
+		{`{"type": "CHAT", "requestId": "r", "data": {"text": "  "}}`, session.InvalidMessage},
+		{`{"type": "CHAT", "requestId": "r", "data": {"text": "a", "stamp": "GG"}}`, session.InvalidMessage},
+	} {
+		sendMessage(t, p2, tt.message)
+		var nack session.NackResponse
+		json.Unmarshal(readUntil(t, p2, "NACK").Data, &nack)
+		if nack.Code != tt.code {
+			t.Errorf("%s should be rejected with %s but %+v", tt.message, tt.code, nack)
+		}
+	}
+
+	for i := 0; i < session.ChatRateLimit; i++ {
+		sendMessage(t, p2, `{"type": "CHAT", "data": {"stamp": "NICE"}}`)
+	}
+	sendMessage(t, p2, `{"type": "CHAT", "requestId": "r", "data": {"stamp": "NICE"}}`)
+	var nack session.NackResponse
+	json.Unmarshal(readUntil(t, p2, "NACK").Data, &nack)
+	if nack.Code != session.RateLimited {
+		t.Errorf("should be rate limited: %+v", nack)
+	}
+}
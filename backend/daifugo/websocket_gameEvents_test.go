@@ -0,0 +1,57 @@
+package daifugo
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"go-playground/daifugo/engine"
+	"go-playground/daifugo/session"
+)
+
+func Test_gameEventsAndSync(t *testing.T) {
+	server := startTestServer(t)
+	p1 := dialPlayer(t, server, "Test_gameEventsAndSync", "p1")
+	readUntil(t, p1, "ADD_PLAYER")
+	p2 := dialPlayer(t, server, "Test_gameEventsAndSync", "p2")
+	readUntil(t, p2, "ADD_PLAYER")
+	spectator := dialPlayer(t, server, "Test_gameEventsAndSync", "watcher?spectator=true")
+	readUntil(t, spectator, "ROOM_STATE")
+
+	sendMessage(t, p1, `{"type": "GAME_START", "data": {"force": true}}`)
+	var snapshot session.GameSnapshotResponse
+	for snapshot.GameState != engine.PlayingCards {
+		json.Unmarshal(readUntil(t, spectator, "GAME_SNAPSHOT").Data, &snapshot)
+	}
+
+	current := p1
+	if snapshot.Players[snapshot.Turn].Name != "p1" {
+		current = p2
+	}
+	sendMessage(t, current, `{"type": "PASS"}`)
+	var passed, turnChanged session.GameEvent
+	json.Unmarshal(readUntil(t, spectator, "GAME_EVENT").Data, &passed)
+	json.Unmarshal(readUntil(t, spectator, "GAME_EVENT").Data, &turnChanged)
+	if passed.Type != session.Passed || passed.Seq != snapshot.Seq+1 {
+		t.Errorf("unexpected event: %+v", passed)
+	}
+	if turnChanged.Type != session.TurnChanged || turnChanged.Seq != snapshot.Seq+2 || turnChanged.Turn == snapshot.Turn {
+		t.Errorf("unexpected event: %+v", turnChanged)
+	}
+
+	// the client missed the last event
+	sendMessage(t, spectator, fmt.Sprintf(`{"type": "SYNC", "data": {"lastSeq": %d}}`, passed.Seq))
+	var resent session.GameEvent
+	json.Unmarshal(readUntil(t, spectator, "GAME_EVENT").Data, &resent)
+	if resent.Seq != turnChanged.Seq || resent.Type != session.TurnChanged {
+		t.Errorf("unexpected resent event: %+v", resent)
+	}
+
+	// the client is too far behind
+	sendMessage(t, spectator, `{"type": "SYNC", "data": {"lastSeq": 0}}`)
+	var resync session.GameSnapshotResponse
+	json.Unmarshal(readUntil(t, spectator, "GAME_SNAPSHOT").Data, &resync)
+	if resync.Seq != turnChanged.Seq || resync.Turn != turnChanged.Turn {
+		t.Errorf("unexpected snapshot: %+v", resync)
+	}
+}
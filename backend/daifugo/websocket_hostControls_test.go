@@ -0,0 +1,114 @@
+package daifugo
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go-playground/daifugo/engine"
+	"go-playground/daifugo/session"
+
+	"github.com/gorilla/websocket"
+)
+
+func readNack(t *testing.T, conn *websocket.Conn) session.NackResponse {
+	t.Helper()
+	var nack session.NackResponse
+	json.Unmarshal(readUntil(t, conn, "NACK").Data, &nack)
+	return nack
+}
+
+// waitRoomState reads ROOM_STATE messages until one satisfies the condition.
+func waitRoomState(t *testing.T, conn *websocket.Conn, condition func(roomState session.RoomStateResponse) bool) {
+	t.Helper()
+	for {
+		var roomState session.RoomStateResponse
+		json.Unmarshal(readUntil(t, conn, "ROOM_STATE").Data, &roomState)
+		if condition(roomState) {
+			return
+		}
+	}
+}
+
+func Test_hostControls(t *testing.T) {
+	server := startTestServer(t)
+	roomName := "Test_hostControls"
+	p1 := dialPlayer(t, server, roomName, "p1")
+	var roomState session.RoomStateResponse
+	json.Unmarshal(readUntil(t, p1, "ROOM_STATE").Data, &roomState)
+	if roomState.Host != "p1" {
+		t.Fatalf("first joiner should be the host: %+v", roomState)
+	}
+	p2 := dialPlayer(t, server, roomName, "p2")
+	readUntil(t, p2, "ROOM_STATE")
+
+	for _, message := range []string{
+		`{"type": "GAME_START", "requestId": "r"}`,
+		`{"type": "REMOVE_PLAYER", "requestId": "r", "data": {"playerName": "p1"}}`,
+		`{"type": "KICK_PLAYER", "requestId": "r", "data": {"playerName": "p1"}}`,
+		`{"type": "LOCK_ROOM", "requestId": "r"}`,
+		`{"type": "SET_RULES", "requestId": "r", "data": {"specialRules": []}}`,
+	} {
+		sendMessage(t, p2, message)
+		if nack := readNack(t, p2); nack.Code != session.NotHost {
+			t.Errorf("%s by non-host should be rejected: %+v", message, nack)
+		}
+	}
+
+	sendMessage(t, p1, `{"type": "SET_RULES", "requestId": "r", "data": {"specialRules": ["Unknown"]}}`)
+	if nack := readNack(t, p1); nack.Code != session.InvalidMessage {
+		t.Errorf("unknown rule should be rejected: %+v", nack)
+	}
+	sendMessage(t, p1, `{"type": "SET_RULES", "data": {"specialRules": ["Yagiri"]}}`)
+	// rules should be changed
+	waitRoomState(t, p2, func(roomState session.RoomStateResponse) bool {
+		return len(roomState.SpecialRules) == 1 && roomState.SpecialRules[0] == engine.Yagiri
+	})
+
+	sendMessage(t, p1, `{"type": "KICK_PLAYER", "data": {"playerName": "p2"}}`)
+	readUntil(t, p2, "KICKED")
+	// p2 should be removed
+	waitRoomState(t, p1, func(roomState session.RoomStateResponse) bool {
+		return len(roomState.PlayerNames) == 1
+	})
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/daifugo/ws/rooms/" + roomName + "/"
+	if _, response, err := websocket.DefaultDialer.Dial(url + "p2", nil); err == nil || response.StatusCode != http.StatusConflict {
+		t.Errorf("kicked player should not be able to join again")
+	}
+
+	spectator := dialPlayer(t, server, roomName, "s1?spectator=true")
+	readUntil(t, spectator, "ROOM_STATE")
+	sendMessage(t, p1, `{"type": "KICK_PLAYER", "data": {"playerName": "s1"}}`)
+	readUntil(t, spectator, "KICKED")
+	if _, response, err := websocket.DefaultDialer.Dial(url + "s1?spectator=true", nil); err == nil || response.StatusCode != http.StatusConflict {
+		t.Errorf("kicked spectator should not be able to join again")
+	}
+
+	p3 := dialPlayer(t, server, roomName, "p3")
+	readUntil(t, p3, "ROOM_STATE")
+	sendMessage(t, p1, `{"type": "REORDER_SEATS", "data": {"playerNames": ["p3", "p1"]}}`)
+	// seats should be reordered
+	waitRoomState(t, p3, func(roomState session.RoomStateResponse) bool {
+		return roomState.PlayerNames[0] == "p3"
+	})
+
+	sendMessage(t, p1, `{"type": "LOCK_ROOM"}`)
+	waitRoomState(t, p3, func(roomState session.RoomStateResponse) bool {
+		return roomState.Locked
+	})
+	if _, response, err := websocket.DefaultDialer.Dial(url + "p4", nil); err == nil || response.StatusCode != http.StatusConflict {
+		t.Errorf("locked room should reject new players")
+	}
+
+	sendMessage(t, p1, `{"type": "TRANSFER_HOST", "data": {"playerName": "p3"}}`)
+	waitRoomState(t, p1, func(roomState session.RoomStateResponse) bool {
+		return roomState.Host == "p3"
+	})
+
+	// the host leaves, then the host goes back to p1
+	p3.Close()
+	waitRoomState(t, p1, func(roomState session.RoomStateResponse) bool {
+		return roomState.Host == "p1"
+	})
+}
@@ -0,0 +1,47 @@
+package daifugo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go-playground/daifugo/engine"
+	"go-playground/daifugo/session"
+
+	"github.com/gorilla/websocket"
+)
+
+func Test_playerState(t *testing.T) {
+	server := startTestServer(t)
+	p1 := dialPlayer(t, server, "Test_playerState", "p1")
+	readUntil(t, p1, "ADD_PLAYER")
+	p2 := dialPlayer(t, server, "Test_playerState", "p2")
+	readUntil(t, p2, "ADD_PLAYER")
+	spectator := dialPlayer(t, server, "Test_playerState", "watcher?spectator=true")
+	readUntil(t, spectator, "ROOM_STATE")
+
+	sendMessage(t, p1, `{"type": "GAME_START", "data": {"force": true}}`)
+	var states [2]engine.PlayerView
+	for i, conn := range []*websocket.Conn{p1, p2} {
+		for len(states[i].HandCards) == 0 {
+			json.Unmarshal(readUntil(t, conn, "PLAYER_STATE").Data, &states[i])
+		}
+	}
+	if states[0].IsMyTurn == states[1].IsMyTurn {
+		t.Errorf("exactly one player must have the turn: %+v", states)
+	}
+	for _, state := range states {
+		if state.IsMyTurn != (len(state.LegalMoves) > 0) {
+			t.Errorf("legal moves must be sent only to the current player: %+v", state)
+		}
+	}
+
+	// spectators get only the public state
+	for {
+		response := readUntil(t, spectator, "GAME_SNAPSHOT")
+		var snapshot session.GameSnapshotResponse
+		json.Unmarshal(response.Data, &snapshot)
+		if snapshot.GameState == engine.PlayingCards {
+			break
+		}
+	}
+}
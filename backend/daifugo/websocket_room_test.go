@@ -0,0 +1,264 @@
+package daifugo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-playground/daifugo/engine"
+
+	"go-playground/daifugo/session"
+
+	"github.com/gorilla/websocket"
+)
+
+// Test_roomUnderConcurrentLoad is meant to be run with -race.
+func Test_roomUnderConcurrentLoad(t *testing.T) {
+	server := startTestServer(t)
+	roomName := "Test_roomUnderConcurrentLoad"
+	messages := []string{
+		`{"type": "READY"}`,
+		`{"type": "GAME_START", "requestId": "start"}`,
+		`{"type": "PASS", "requestId": "pass", "data": {}}`,
+		`{"type": "SUBMIT_CARDS", "requestId": "submit", "data": {"cards": [{"number": 3, "value": 3, "cardType": "Spade"}]}}`,
+		`{"type": "UNKNOWN", "requestId": "unknown"}`,
+		`broken json`,
+	}
+
+	conns := make([]*websocket.Conn, 6)
+	for i := range conns {
+		conns[i] = dialPlayer(t, server, roomName, fmt.Sprintf("p%d", i))
+		readUntil(t, conns[i], "ADD_PLAYER")
+		// keep reading so that the client is not disconnected as a slow client
+		go func() {
+			conns[i].SetReadDeadline(time.Time{})
+			for {
+				if _, _, err := conns[i].ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 30; j++ {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(messages[j%len(messages)])); err != nil {
+					t.Errorf("write error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				response, err := http.Get(server.URL + "/daifugo/debug/rooms/" + roomName)
+				if err != nil {
+					t.Errorf("get error: %v", err)
+					return
+				}
+				response.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	snapshot, _ := session.GetRoom(roomName).GameJSON()
+	var game engine.Game
+	json.Unmarshal(snapshot, &game)
+	if len(game.Players) == 0 {
+		t.Errorf("players should have joined")
+	}
+}
+
+func Test_createAndDeleteRoom(t *testing.T) {
+	server := startTestServer(t)
+	url := server.URL + "/daifugo/rooms/Test_createAndDeleteRoom"
+	response, err := http.Post(url, "application/json", strings.NewReader(`{"creator": "p1", "maxPlayers": 4}`))
+	if err != nil {
+		t.Fatalf("post error: %v", err)
+	}
+	var created session.CreateRoomResponse
+	json.NewDecoder(response.Body).Decode(&created)
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || created.Creator != "p1" || created.MaxPlayers != 4 ||
+		created.Status != session.RoomStatusWaiting || created.OwnerToken == "" {
+		t.Fatalf("unexpected response: %d %+v", response.StatusCode, created)
+	}
+
+	request, _ := http.NewRequest(http.MethodPost, url, nil)
+	request.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	response, _ = http.DefaultClient.Do(request)
+	var conflict struct {
+		Code session.ErrorCode `json:"code"`
+		Error string `json:"error"`
+	}
+	json.NewDecoder(response.Body).Decode(&conflict)
+	response.Body.Close()
+	if response.StatusCode != http.StatusConflict || conflict.Code != session.RoomAlreadyExists {
+		t.Errorf("creating the same room should conflict but %d %+v", response.StatusCode, conflict)
+	}
+	if conflict.Error != "Room already exists: Test_createAndDeleteRoom" {
+		t.Errorf("the error should be in the language of Accept-Language: %s", conflict.Error)
+	}
+
+	conn := dialPlayer(t, server, "Test_createAndDeleteRoom", "p2")
+	readUntil(t, conn, "ADD_PLAYER")
+
+	request, _ = http.NewRequest(http.MethodDelete, url, nil)
+	request.Header.Set("X-Owner-Token", "wrong")
+	response, _ = http.DefaultClient.Do(request)
+	response.Body.Close()
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("deleting with a wrong token should be forbidden but %d", response.StatusCode)
+	}
+
+	request.Header.Set("X-Owner-Token", created.OwnerToken)
+	response, _ = http.DefaultClient.Do(request)
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("deleting by the owner should succeed but %d", response.StatusCode)
+	}
+	readUntil(t, conn, "ROOM_CLOSED")
+	if session.GetRoom("Test_createAndDeleteRoom") != nil {
+		t.Errorf("room should be deleted")
+	}
+}
+
+func Test_createRoomWithTwoDecks(t *testing.T) {
+	server := startTestServer(t)
+	for _, tt := range []struct {
+		body string
+		status int
+		decks int
+		maxPlayers int
+	}{
+		{`{"maxPlayers": 8}`, http.StatusOK, 2, 8},
+		{`{"decks": 2}`, http.StatusOK, 2, engine.MaxPlayersWithTwoDecks},
+		{`{"maxPlayers": 11}`, http.StatusBadRequest, 0, 0},
+		{`{"maxPlayers": 8, "decks": 1}`, http.StatusBadRequest, 0, 0},
+		{`{"decks": 3}`, http.StatusBadRequest, 0, 0},
+	} {
+		response, err := http.Post(server.URL + "/daifugo/rooms/Test_createRoomWithTwoDecks", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("post error: %v", err)
+		}
+		var created session.CreateRoomResponse
+		json.NewDecoder(response.Body).Decode(&created)
+		response.Body.Close()
+		if response.StatusCode != tt.status || created.Decks != tt.decks || created.MaxPlayers != tt.maxPlayers {
+			t.Errorf("%s: unexpected response: %d %+v", tt.body, response.StatusCode, created)
+		}
+		if room := session.GetRoom("Test_createRoomWithTwoDecks"); room != nil {
+			room.CloseByOwner(created.OwnerToken)
+		}
+	}
+}
+
+func Test_joinRejectedWhenFull(t *testing.T) {
+	server := startTestServer(t)
+	response, _ := http.Post(server.URL + "/daifugo/rooms/Test_joinRejectedWhenFull", "application/json", strings.NewReader(`{"maxPlayers": 2}`))
+	response.Body.Close()
+	readUntil(t, dialPlayer(t, server, "Test_joinRejectedWhenFull", "p1"), "ADD_PLAYER")
+	readUntil(t, dialPlayer(t, server, "Test_joinRejectedWhenFull", "p2"), "ADD_PLAYER")
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/daifugo/ws/rooms/Test_joinRejectedWhenFull/p3"
+	_, response, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatalf("joining a full room should fail")
+	}
+	var body struct {
+		Code session.ErrorCode `json:"code"`
+	}
+	json.NewDecoder(response.Body).Decode(&body)
+	if response.StatusCode != http.StatusConflict || body.Code != session.RoomFull {
+		t.Errorf("unexpected response: %d %v", response.StatusCode, body.Code)
+	}
+}
+
+func Test_privateRoom(t *testing.T) {
+	server := startTestServer(t)
+	response, err := http.Post(server.URL + "/daifugo/rooms/Test_privateRoom", "application/json", strings.NewReader(`{"passphrase": "open sesame"}`))
+	if err != nil {
+		t.Fatalf("post error: %v", err)
+	}
+	var created session.CreateRoomResponse
+	json.NewDecoder(response.Body).Decode(&created)
+	response.Body.Close()
+	if !created.Private || created.InviteCode == "" {
+		t.Fatalf("room should be private with an invite code: %+v", created)
+	}
+
+	if list := getRoomList(t, server.URL + "/daifugo/rooms"); len(list.Rooms) != 0 {
+		t.Errorf("private room should be hidden: %+v", list)
+	}
+	if list := getRoomList(t, server.URL + "/daifugo/rooms?code=" + created.InviteCode); len(list.Rooms) != 1 {
+		t.Errorf("private room should be listed with the invite code: %+v", list)
+	}
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/daifugo/ws/rooms/Test_privateRoom/"
+	for _, query := range []string{"", "?code=WRONG", "?passphrase=wrong"} {
+		_, response, err := websocket.DefaultDialer.Dial(url + "p1" + query, nil)
+		if err == nil || response.StatusCode != http.StatusForbidden {
+			t.Errorf("joining with %q should be forbidden", query)
+		}
+	}
+	readUntil(t, dialPlayer(t, server, "Test_privateRoom", "p1?code=" + created.InviteCode), "ADD_PLAYER")
+	readUntil(t, dialPlayer(t, server, "Test_privateRoom", "p2?passphrase=open%20sesame"), "ADD_PLAYER")
+}
+
+func Test_teamRoom(t *testing.T) {
+	server := startTestServer(t)
+	session.CreateRoom("Test_teamRoom", "", session.RoomOptions{Teams: true, PartnerPassCards: 1})
+	players := make([]*websocket.Conn, 4)
+	for i := range players {
+		players[i] = dialPlayer(t, server, "Test_teamRoom", fmt.Sprintf("p%d", i+1))
+		readUntil(t, players[i], "ADD_PLAYER")
+	}
+	sendMessage(t, players[0], `{"type": "GAME_START", "data": {"force": true}}`)
+	var gameStart session.GameStartResponse
+	json.Unmarshal(readUntil(t, players[0], "GAME_START").Data, &gameStart)
+	for i, player := range gameStart.Players {
+		if player.Team != i%2+1 {
+			t.Errorf("partners should sit opposite: %+v", gameStart.Players)
+		}
+	}
+
+	var view engine.PlayerView
+	json.Unmarshal(readUntil(t, players[0], "PLAYER_STATE").Data, &view)
+	if view.PartnerPassCards != 1 {
+		t.Fatalf("p1 should be able to pass a card: %+v", view)
+	}
+	sendMessage(t, players[0], `{"type": "SUBMIT_CARDS", "requestId": "r1", "data": {"cards": ["` + engine.FormatCard(view.HandCards[0]) + `"]}}`)
+	var nack session.NackResponse
+	json.Unmarshal(readUntil(t, players[0], "NACK").Data, &nack)
+	if nack.Code != session.GameNotInProgress {
+		t.Errorf("cards cannot be submitted while passing: %+v", nack)
+	}
+	sendMessage(t, players[0], `{"type": "PASS_TO_PARTNER", "data": {"cards": ["` + engine.FormatCard(view.HandCards[0]) + `"]}}`)
+	for _, player := range players[1:] {
+		sendMessage(t, player, `{"type": "PASS_TO_PARTNER", "data": {"cards": []}}`)
+	}
+	// the partner sitting opposite p1 gets the card when everyone has passed
+	seat := slices.IndexFunc(gameStart.Players, func(player engine.PublicPlayer) bool { return player.Name == "p1" })
+	var partner int
+	fmt.Sscanf(gameStart.Players[(seat+2)%4].Name, "p%d", &partner)
+	passed := view.HandCards[0]
+	for {
+		json.Unmarshal(readUntil(t, players[partner-1], "PLAYER_STATE").Data, &view)
+		if slices.ContainsFunc(view.HandCards, func(card engine.Card) bool { return engine.SameCard(card, passed) }) {
+			break
+		}
+	}
+}
@@ -4,6 +4,8 @@ package main
 import (
 	"go-playground/daifugo"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,8 +14,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// CORSヘッダーの設定
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Owner-Token")
 
 		// プリフライトリクエストの対応
 		if c.Request.Method == "OPTIONS" {
@@ -49,8 +51,18 @@ func main() {
 	router.GET("/daifugo/debug/rooms/:roomName", daifugo.DebugGetGameState)
 	router.GET("/daifugo/ws/rooms/:roomName/:playerName", daifugo.WebSocketDaifugoHandler)
 	router.GET("/daifugo/rooms", daifugo.ListRoomsHandler)
+	router.GET("/daifugo/lobby/events", daifugo.LobbyEventsHandler)
 	router.POST("/daifugo/rooms/:roomName", daifugo.CreateRoomHandler)
-	
+	router.DELETE("/daifugo/rooms/:roomName", daifugo.DeleteRoomHandler)
+
+	// 誰もいない部屋を一定時間後に削除する
+	roomIdleTTL := 10 * time.Minute
+	if ttl, err := time.ParseDuration(os.Getenv("DAIFUGO_ROOM_IDLE_TTL")); err == nil {
+		roomIdleTTL = ttl
+	}
+	stopRoomReaper := daifugo.StartRoomReaper(roomIdleTTL)
+	defer stopRoomReaper()
+
 
 	// サーバーを起動
 	router.Run(":8080")
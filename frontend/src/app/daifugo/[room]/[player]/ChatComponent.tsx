@@ -0,0 +1,65 @@
+"use client";
+
+import { useState } from "react";
+
+export type ChatMessage = {
+  playerName: string;
+  channel: "players" | "spectators";
+  text?: string;
+  stamp?: string;
+  sentAt: string;
+};
+
+const stamps = {
+  GOOD: "👍",
+  NICE: "👏",
+  THANKS: "🙏",
+  SORRY: "🙇",
+  HURRY: "⏰",
+  GG: "🤝",
+} as const;
+
+export default function ChatComponent({
+  messages,
+  handleSend,
+}: {
+  messages: ChatMessage[];
+  handleSend: (data: { text?: string; stamp?: string }) => void;
+}) {
+  const [text, setText] = useState<string>("");
+  return (
+    <div className="m-4">
+      <div>
+        {messages.map((message, idx) => (
+          <div key={idx}>
+            {message.channel === "spectators" && "[観戦] "}
+            {message.playerName}:{" "}
+            {message.stamp
+              ? stamps[message.stamp as keyof typeof stamps] ?? message.stamp
+              : message.text}
+          </div>
+        ))}
+      </div>
+      <input
+        type="text"
+        value={text}
+        maxLength={200}
+        onChange={(e) => setText(e.target.value)}
+      />
+      <button
+        disabled={text.trim() === ""}
+        onClick={() => {
+          handleSend({ text });
+          setText("");
+        }}
+      >
+        送信
+      </button>
+      {Object.entries(stamps).map(([stamp, label]) => (
+        <button key={stamp} onClick={() => handleSend({ stamp })}>
+          {label}
+        </button>
+      ))}
+    </div>
+  );
+}
@@ -2,8 +2,9 @@
 
 import clsx from "clsx";
 import { useParams } from "next/navigation";
-import { useCallback, useEffect, useState } from "react";
+import { useCallback, useEffect, useRef, useState } from "react";
 import CardComponent from "./CardComponent";
+import ChatComponent, { ChatMessage } from "./ChatComponent";
 
 type CardType = "Spade" | "Club" | "Heart" | "Diamond" | "Joker";
 
@@ -22,36 +23,123 @@ type AddPlayerResponse = {
 type GameStartResponse = {
   type: "GAME_START";
   data: {
-    handCards: Card[];
     players: Player[];
   };
 };
-type MyHandCardResponse = {
-  type: "MY_HAND_CARD";
+type PlayerStateResponse = {
+  type: "PLAYER_STATE";
   data: {
+    playerName: string;
     handCards: Card[];
+    legalMoves: Card[][];
+    isMyTurn: boolean;
+    partnerPassCards?: number;
   };
 };
-
-type GameDataResponse = {
-  type: "GAME_DATA";
+type GameSnapshotResponse = {
+  type: "GAME_SNAPSHOT";
   data: {
+    seq: number;
     players: Player[];
     gameState: GameState;
     submitModes: SubmitMode[];
-    specialRule: SpecialRule[];
+    specialRules: SpecialRule[];
     topFieldCards: Card[];
     turn: number;
     playersByRank: string[];
   };
 };
-type MessageResponse = { type: "MESSAGE"; data: { message: string } };
+type GameEvent = {
+  seq: number;
+  type:
+    | "CARDS_PLAYED"
+    | "PASSED"
+    | "FIELD_CLEARED"
+    | "PLAYER_FINISHED"
+    | "MODE_CHANGED"
+    | "TURN_CHANGED"
+    | "GAME_STATE_CHANGED";
+  playerName?: string;
+  cards?: Card[];
+  mode?: SubmitMode;
+  enabled?: boolean;
+  turn: number;
+  gameState?: GameState;
+};
+type GameEventResponse = { type: "GAME_EVENT"; data: GameEvent };
+type MessageResponse = {
+  type: "MESSAGE";
+  data: { message: string; code?: string };
+};
+type AckResponse = {
+  type: "ACK";
+  data: { requestId: string; requestType: string };
+};
+type NackResponse = {
+  type: "NACK";
+  data: {
+    requestId: string;
+    requestType: string;
+    code: string;
+    message: string;
+  };
+};
+type RoomStateResponse = {
+  type: "ROOM_STATE";
+  data: {
+    host: string;
+    locked: boolean;
+    playerNames: string[];
+    readyPlayerNames: string[];
+    specialRules: SpecialRule[];
+  };
+};
+type LocaleResponse = {
+  type: "LOCALE";
+  data: { language: string; roleNames: Record<Role, string> };
+};
+type ChatResponse = { type: "CHAT"; data: ChatMessage };
+type VoteResponse = {
+  type: "VOTE";
+  data: { kind: string; voters: string[]; required: number };
+};
+type PlayerLeftResponse = {
+  type: "PLAYER_LEFT";
+  data: { playerName: string; policy: "FORFEIT" | "BOT" | "HOLD_SEAT" };
+};
+type UndoRequestedResponse = {
+  type: "UNDO_REQUESTED";
+  data: { playerName: string; approvedBy: string[]; expiresAt: string };
+};
+type UndoResolvedResponse = {
+  type: "UNDO_RESOLVED";
+  data: {
+    playerName: string;
+    result: "APPLIED" | "REJECTED" | "EXPIRED";
+    rejectedBy?: string;
+  };
+};
+type ChatHistoryResponse = {
+  type: "CHAT_HISTORY";
+  data: { messages: ChatMessage[] };
+};
 type Response =
   | AddPlayerResponse
+  | LocaleResponse
+  | ChatResponse
+  | ChatHistoryResponse
+  | RoomStateResponse
+  | AckResponse
+  | NackResponse
   | GameStartResponse
   | MessageResponse
-  | MyHandCardResponse
-  | GameDataResponse;
+  | PlayerStateResponse
+  | GameSnapshotResponse
+  | GameEventResponse
+  | VoteResponse
+  | PlayerLeftResponse
+  | UndoRequestedResponse
+  | UndoResolvedResponse;
 
 type Card = {
   number: number;
@@ -63,9 +151,15 @@ type Player = {
   name: string;
   numHandCards: number;
   role: Role;
+  team?: number;
 };
-type Role = "Daifugo" | "Fugo" | "DaHeiminifugo" | "Hinmin" | "Daihinmin";
-type GameState = "WaitingForPlayers" | "PlayingCards" | "GameEnded";
+type Role = "Daifugo" | "Fugo" | "Heimin" | "Hinmin" | "Daihinmin";
+type GameState =
+  | "WaitingForPlayers"
+  | "PassingToPartner"
+  | "PlayingCards"
+  | "Paused"
+  | "GameEnded";
 type SubmitMode = "Normal" | "ShibariMode" | "KakumeiMode" | "KaidanMode";
 type SpecialRule = "Normal" | "ShibariMode" | "KakumeiMode" | "KaidanMode";
 
@@ -85,7 +179,57 @@ export default function Page() {
   const [isEnteredRoom, setIsEnteredRoom] = useState<boolean>(false);
   const [players, setPlayers] = useState<Player[]>([]);
   const [playerNameByRank, setPlayerNameByRank] = useState<string[]>([]);
+  const [host, setHost] = useState<string>("");
+  const [chatMessages, setChatMessages] = useState<ChatMessage[]>([]);
+  const [readyPlayerNames, setReadyPlayerNames] = useState<string[]>([]);
+  const [partnerPassCards, setPartnerPassCards] = useState<number>(0);
+  const [undoRequest, setUndoRequest] = useState<
+    UndoRequestedResponse["data"] | undefined
+  >(undefined);
+  const [roleNames, setRoleNames] = useState<Partial<Record<Role, string>>>(
+    {}
+  );
+  const isReady = readyPlayerNames.includes(playerName as string);
+  const isHost = host === playerName;
   const currentPlayer = players.length == 0 ? undefined : players[turn].name;
+  // seq of the last applied game event. -1 until the first snapshot
+  const seq = useRef<number>(-1);
+
+  const applyGameEvent = useCallback((event: GameEvent) => {
+    switch (event.type) {
+      case "CARDS_PLAYED":
+        setPlayers((prev) =>
+          prev.map((player) =>
+            player.name === event.playerName
+              ? {
+                  ...player,
+                  numHandCards: player.numHandCards - event.cards!.length,
+                }
+              : player
+          )
+        );
+        setTopFieldCards(event.cards!);
+        break;
+      case "FIELD_CLEARED":
+        setTopFieldCards([]);
+        break;
+      case "PLAYER_FINISHED":
+        setPlayerNameByRank((prev) => [...prev, event.playerName!]);
+        break;
+      case "MODE_CHANGED":
+        setSubmitModes((prev) => {
+          const modes = prev.filter((mode) => mode !== event.mode);
+          return event.enabled ? [...modes, event.mode!] : modes;
+        });
+        break;
+      case "TURN_CHANGED":
+        setTurn(event.turn);
+        break;
+      case "GAME_STATE_CHANGED":
+        setGameState(event.gameState!);
+        break;
+    }
+  }, []);
 
   const handleData = useCallback(
     (responseStr: string) => {
@@ -98,30 +242,76 @@ export default function Page() {
             return { name, numHandCards: 0, role: "Heimin" };
           })
         );
+      } else if (response.type === "LOCALE") {
+        setRoleNames(response.data.roleNames);
       } else if (response.type === "GAME_START") {
         const gameStartData = response.data;
         setGameState("PlayingCards");
-        setTurn(0);
-        setHandCards(gameStartData.handCards);
         setPlayers(gameStartData.players);
       } else if (response.type === "MESSAGE") {
         setMessages((prev) => [...prev, response.data.message]);
-      } else if (response.type === "MY_HAND_CARD") {
+      } else if (response.type === "CHAT") {
+        setChatMessages((prev) => [...prev, response.data]);
+      } else if (response.type === "CHAT_HISTORY") {
+        setChatMessages(response.data.messages);
+      } else if (response.type === "ROOM_STATE") {
+        setHost(response.data.host);
+        setReadyPlayerNames(response.data.readyPlayerNames);
+      } else if (response.type === "ACK") {
+        console.log("ack: " + response.data.requestId);
+      } else if (response.type === "NACK") {
+        setMessages((prev) => [...prev, response.data.message]);
+      } else if (response.type === "PLAYER_STATE") {
         setSelectedCards(new Set());
         setHandCards(response.data.handCards);
-      } else if (response.type === "GAME_DATA") {
-        console.log(response.data.players);
+        setPartnerPassCards(response.data.partnerPassCards ?? 0);
+      } else if (response.type === "GAME_SNAPSHOT") {
+        seq.current = response.data.seq;
         setPlayers(response.data.players);
         setSubmitModes(response.data.submitModes);
         setGameState(response.data.gameState);
         setTopFieldCards(response.data.topFieldCards);
         setTurn(response.data.turn);
-        setPlayerNameByRank(response.data.playersByRank);
+        setPlayerNameByRank(response.data.playersByRank ?? []);
+      } else if (response.type === "VOTE") {
+        const { kind, voters, required } = response.data;
+        setMessages((prev) => [
+          ...prev,
+          `${kind}: ${voters.join(", ")} (${voters.length}/${required})`,
+        ]);
+      } else if (response.type === "PLAYER_LEFT") {
+        const { playerName, policy } = response.data;
+        setMessages((prev) => [
+          ...prev,
+          policy === "BOT"
+            ? `${playerName}が退出しました。BOTが代わりにプレイします`
+            : `${playerName}が退出しました。席を保持して戻りを待ちます`,
+        ]);
+      } else if (response.type === "UNDO_REQUESTED") {
+        setUndoRequest(response.data);
+      } else if (response.type === "UNDO_RESOLVED") {
+        setUndoRequest(undefined);
+      } else if (response.type === "GAME_EVENT") {
+        const event = response.data;
+        if (event.seq <= seq.current) {
+          return;
+        }
+        if (event.seq !== seq.current + 1) {
+          // missed some events. the server resends them or a snapshot
+          ws?.send(
+            JSON.stringify({ type: "SYNC", data: { lastSeq: seq.current } })
+          );
+          return;
+        }
+        seq.current = event.seq;
+        applyGameEvent(event);
       } else {
         console.log("unknown response type");
       }
     },
     [
+      ws,
+      applyGameEvent,
       setPlayers,
       setGameState,
       setTurn,
@@ -156,6 +346,12 @@ export default function Page() {
       console.log("Disconnected from WebSocket");
     };
   }, [ws, playerName, handleData]);
+  const chat = (
+    <ChatComponent
+      messages={chatMessages}
+      handleSend={(data) => ws?.send(JSON.stringify({ type: "CHAT", data }))}
+    />
+  );
   const applyPlayerNameChange = (ws: WebSocket) => {
     setIsEnteredRoom(true);
     ws.send(JSON.stringify({ type: "ADD_PLAYER", data: { playerName } }));
@@ -168,6 +364,14 @@ export default function Page() {
           return <div key={playerName}>{playerName}</div>;
         })}
         <button
+          onClick={() => {
+            ws?.send(JSON.stringify({ type: isReady ? "UNREADY" : "READY" }));
+          }}
+        >
+          {isReady ? "準備取り消し" : "準備OK"}
+        </button>
+        <button
+          disabled={!isHost}
           onClick={() => {
             ws?.send(JSON.stringify({ type: "GAME_START" }));
           }}
@@ -178,9 +382,47 @@ export default function Page() {
     );
   }
 
-  if (gameState === "PlayingCards") {
+  if (
+    gameState === "PlayingCards" ||
+    gameState === "PassingToPartner" ||
+    gameState === "Paused"
+  ) {
     return (
       <div>
+        {gameState === "Paused" ? (
+          <div>
+            一時停止中{" "}
+            <button
+              onClick={() => {
+                ws?.send(JSON.stringify({ type: "RESUME" }));
+              }}
+            >
+              再開
+            </button>
+          </div>
+        ) : (
+          <button
+            onClick={() => {
+              ws?.send(JSON.stringify({ type: "PAUSE" }));
+            }}
+          >
+            一時停止
+          </button>
+        )}{" "}
+        <button
+          onClick={() => {
+            ws?.send(JSON.stringify({ type: "VOTE_ABORT" }));
+          }}
+        >
+          中止に投票
+        </button>{" "}
+        <button
+          onClick={() => {
+            ws?.send(JSON.stringify({ type: "VOTE_REDEAL" }));
+          }}
+        >
+          配り直しに投票
+        </button>
         {players.map((player) => {
           return (
             <div key={player.name} className="m-4">
@@ -190,7 +432,8 @@ export default function Page() {
                 >
                   {player.name}
                 </div>
-                <div>{player.role}</div>
+                <div>{roleNames[player.role] ?? player.role}</div>
+                {player.team ? <div>チーム{player.team}</div> : null}
               </div>
               <div>カード枚数: {player.numHandCards}</div>
             </div>
@@ -245,6 +488,21 @@ export default function Page() {
               />
             );
           })}
+        {partnerPassCards > 0 && (
+          <button
+            disabled={selectedCards.size > partnerPassCards}
+            onClick={() => {
+              ws?.send(
+                JSON.stringify({
+                  type: "PASS_TO_PARTNER",
+                  data: { cards: Array.from(selectedCards) },
+                })
+              );
+            }}
+          >
+            パートナーに渡す(最大{partnerPassCards}枚)
+          </button>
+        )}{" "}
         <button
           onClick={() => {
             ws?.send(
@@ -268,13 +526,43 @@ export default function Page() {
           }}
         >
           パス
+        </button>{" "}
+        <button
+          disabled={undoRequest !== undefined}
+          onClick={() => {
+            ws?.send(JSON.stringify({ type: "REQUEST_UNDO" }));
+          }}
+        >
+          待った
         </button>
+        {undoRequest !== undefined &&
+          undoRequest.playerName !== playerName &&
+          !undoRequest.approvedBy.includes(playerName as string) && (
+            <div>
+              {undoRequest.playerName}が待ったをしています{" "}
+              <button
+                onClick={() => {
+                  ws?.send(JSON.stringify({ type: "APPROVE_UNDO" }));
+                }}
+              >
+                認める
+              </button>{" "}
+              <button
+                onClick={() => {
+                  ws?.send(JSON.stringify({ type: "REJECT_UNDO" }));
+                }}
+              >
+                認めない
+              </button>
+            </div>
+          )}
         {submitModes.map((mode, idx) => (
           <div key={idx}>{mode}</div>
         ))}
         {messages.reverse().map((message, idx) => (
           <div key={idx}>{message}</div>
         ))}
+        {chat}
         {JSON.stringify(debugMessages)}
       </div>
     );
@@ -289,13 +577,27 @@ export default function Page() {
       ></input>
       <ul>
         {players.map((op) => {
-          return <li key={op.name}>{op.name}</li>;
+          return (
+            <li key={op.name}>
+              {op.name}
+              {readyPlayerNames.includes(op.name) && " (準備OK)"}
+            </li>
+          );
         })}
       </ul>
+      <input
+        type="button"
+        value={isReady ? "準備取り消し" : "準備OK"}
+        onClick={() => {
+          ws?.send(JSON.stringify({ type: isReady ? "UNREADY" : "READY" }));
+        }}
+      />
       <div>{`RoomId: ${room}`}</div>
+      {chat}
+      <div>{`Host: ${host}`}</div>
       <input
         type="button"
-        disabled={players.length <= 1}
+        disabled={!isHost || players.length <= 1}
         onClick={() => {
           ws?.send(JSON.stringify({ type: "GAME_START" }));
         }}
@@ -1,25 +1,55 @@
 "use client";
 
-import { ChangeEvent, useState } from "react";
+import { ChangeEvent, useEffect, useState } from "react";
+
+type RoomSummary = {
+  name: string;
+  creator: string;
+  createdAt: string;
+  maxPlayers: number;
+  status: "Waiting" | "Playing" | "Closed";
+  numPlayers: number;
+  numSpectators: number;
+  gameState: string;
+  specialRules: string[];
+  joinable: boolean;
+};
+
+type LobbyEvent = {
+  type: "ROOM_UPDATED" | "ROOM_REMOVED";
+  room: RoomSummary;
+};
 
 export default function Home() {
   const [room, setRoom] = useState<string>("");
-  const [rooms, setRooms] = useState<string>([]);
-  //const [messages, setMessages] = useState<string[]>([]);
+  const [rooms, setRooms] = useState<RoomSummary[]>([]);
   const onChangeRoom = (e: ChangeEvent<HTMLInputElement>) => {
     setRoom(e.target.value);
   };
-  const fetchRoom = () => {
-    const _ = async () => {
-      const scheme = process.env.NODE_ENV === "development" ? "http" : "https";
-      const ret = await fetch(
-        `${scheme}://${process.env.NEXT_PUBLIC_BACKEND_DOMAIN}/daifugo/rooms`
-      );
-      const json = await ret.json();
-      setRooms(json);
-    };
-    _();
-  };
+
+  // 部屋一覧の変更をSSEで受け取る
+  useEffect(() => {
+    const scheme = process.env.NODE_ENV === "development" ? "http" : "https";
+    const events = new EventSource(
+      `${scheme}://${process.env.NEXT_PUBLIC_BACKEND_DOMAIN}/daifugo/lobby/events`
+    );
+    events.addEventListener("snapshot", (event) => {
+      setRooms(JSON.parse(event.data) as RoomSummary[]);
+    });
+    events.addEventListener("room", (event) => {
+      const lobbyEvent = JSON.parse(event.data) as LobbyEvent;
+      setRooms((prev) => {
+        const others = prev.filter((r) => r.name !== lobbyEvent.room.name);
+        if (lobbyEvent.type === "ROOM_REMOVED") {
+          return others;
+        }
+        return [...others, lobbyEvent.room].sort((a, b) =>
+          a.name.localeCompare(b.name)
+        );
+      });
+    });
+    return () => events.close();
+  }, []);
 
   const createRoom = () => {
     const _ = async () => {
@@ -34,8 +64,16 @@ export default function Home() {
 
   return (
     <div className="grid grid-rows-[20px_1fr_20px] items-center justify-items-center min-h-screen p-8 pb-20 gap-16 sm:p-20 font-[family-name:var(--font-geist-sans)]">
-      <input type="button" value="部屋一覧取得" onClick={fetchRoom}></input>
-      {JSON.stringify(rooms)}
+      <ul>
+        {rooms.map((r) => {
+          return (
+            <li key={r.name}>
+              {`${r.name} (${r.numPlayers}/${r.maxPlayers}) ${r.status}`}
+              {r.numSpectators > 0 && ` 観戦: ${r.numSpectators}`}
+            </li>
+          );
+        })}
+      </ul>
       <input type="text" value={room} onChange={onChangeRoom}></input>
       <input type="button" value="部屋作成" onClick={createRoom}></input>
     </div>